@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Role of telegram user or chat, higher role includes rights of lower ones
+type Role int
+
+const (
+	RoleNone Role = iota // unknown user, can't do anything
+	RoleGuest
+	RoleMember
+	RoleAdmin
+)
+
+func (role Role) String() string {
+	switch role {
+	case RoleGuest:
+		return "guest"
+	case RoleMember:
+		return "member"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// convert role name from config to Role
+func ParseRole(name string) (Role, error) {
+	switch name {
+	case "guest":
+		return RoleGuest, nil
+	case "member":
+		return RoleMember, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return RoleNone, fmt.Errorf("unknown role %q", name)
+	}
+}
+
+var accessMutex sync.RWMutex
+var userRoles = make(map[int64]Role)
+var chatRoles = make(map[int64]Role)
+
+// set allowed users and chats, everybody else gets RoleNone
+func SetAccessList(users map[int64]Role, chats map[int64]Role) {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	userRoles = users
+	chatRoles = chats
+}
+
+// role of the user who sent the message, chat role is used if it is higher
+func RoleOf(info *Info) Role {
+	accessMutex.RLock()
+	defer accessMutex.RUnlock()
+	role := userRoles[info.UserID()]
+	if chatRole := chatRoles[info.ChatID()]; chatRole > role {
+		role = chatRole
+	}
+	return role
+}
+
+// set minimal role required to run the route
+func (route *Route) Requires(role Role) *Route {
+	route.Role = role
+	return route
+}
+
+// allow handler only for users with role required by the route
+func AccessMiddleware(denied Hanlder) Middleware {
+	return AuthMiddleware(func(info *Info, route *Route) bool {
+		return RoleOf(info) >= route.Role
+	}, denied)
+}
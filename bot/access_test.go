@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/telegram-command-reader/i18n"
+)
+
+func messageFrom(userID int64, chatID int64) *Info {
+	return &Info{source: &tgbotapi.Message{From: &tgbotapi.User{ID: userID}, Chat: &tgbotapi.Chat{ID: chatID}}}
+}
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole("admin")
+	if err != nil || role != RoleAdmin {
+		t.Fatalf("expected admin, got %v %v", role, err)
+	}
+	if _, err := ParseRole("root"); err == nil {
+		t.Fatalf("expected error for unknown role")
+	}
+}
+
+func TestRoleOfUnknownUser(t *testing.T) {
+	SetAccessList(map[int64]Role{1: RoleAdmin}, map[int64]Role{})
+	if role := RoleOf(messageFrom(2, 2)); role != RoleNone {
+		t.Fatalf("expected none, got %v", role)
+	}
+}
+
+func TestRoleOfUsesHigherChatRole(t *testing.T) {
+	SetAccessList(map[int64]Role{1: RoleGuest}, map[int64]Role{-100: RoleMember})
+	if role := RoleOf(messageFrom(1, -100)); role != RoleMember {
+		t.Fatalf("expected member, got %v", role)
+	}
+	if role := RoleOf(messageFrom(1, 1)); role != RoleGuest {
+		t.Fatalf("expected guest, got %v", role)
+	}
+}
+
+func TestRoleOfCallbackUsesPressingUser(t *testing.T) {
+	SetAccessList(map[int64]Role{1: RoleAdmin}, map[int64]Role{})
+	info := &Info{
+		source:   &tgbotapi.Message{From: &tgbotapi.User{ID: 99}, Chat: &tgbotapi.Chat{ID: 5}},
+		callback: &tgbotapi.CallbackQuery{From: &tgbotapi.User{ID: 1}},
+	}
+	if role := RoleOf(info); role != RoleAdmin {
+		t.Fatalf("expected admin, got %v", role)
+	}
+}
+
+func TestAccessMiddlewareAdminOnly(t *testing.T) {
+	resetRoutes()
+	SetAccessList(map[int64]Role{1: RoleAdmin, 2: RoleMember}, map[int64]Role{})
+	called := 0
+	denied := 0
+	Use(AccessMiddleware(func(info *Info) {
+		denied++
+	}))
+	route := AddHandler(NewCommandMatcher("/delete_([0-9]+)"), func(message *Info) {
+		called++
+	}).Requires(RoleAdmin)
+	dispatch(route, route.handler, messageFrom(2, 2))
+	dispatch(route, route.handler, messageFrom(3, 3))
+	dispatch(route, route.handler, messageFrom(1, 1))
+	if called != 1 || denied != 2 {
+		t.Fatalf("expected 1 call and 2 denials, got %d and %d", called, denied)
+	}
+}
+
+func TestLanguageFromTelegramAndSetting(t *testing.T) {
+	info := &Info{source: &tgbotapi.Message{From: &tgbotapi.User{ID: 501, LanguageCode: "en-GB"}, Chat: &tgbotapi.Chat{ID: 501}}}
+	if info.Language() != i18n.English {
+		t.Fatalf("expected language of telegram app, got %s", info.Language())
+	}
+	i18n.SetUserLanguage(501, i18n.Russian)
+	if info.Language() != i18n.Russian {
+		t.Fatalf("expected chosen language, got %s", info.Language())
+	}
+	if (&Info{}).Language() != i18n.DefaultLanguage {
+		t.Fatalf("expected default language for unknown user")
+	}
+}
@@ -0,0 +1,179 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/telegram-command-reader/i18n"
+)
+
+// how long inline keyboard buttons keep working
+var CallbackTTL = 7 * 24 * time.Hour
+
+// Action is a serializable reply callback, it is encoded into buttons created by NewButton,
+// or saved for the sent message with plain buttons, handler registered with the same name
+// is called when a button is pressed
+type Action struct {
+	Name    string            `json:"name"`
+	Params  map[string]string `json:"params,omitempty"`
+	Expires time.Time         `json:"expires"`
+}
+
+// handler of button press, info.Text is the data of the pressed button
+type ActionHandler func(info *Info, params map[string]string)
+
+func NewAction(name string, params map[string]string) *Action {
+	return &Action{Name: name, Params: params}
+}
+
+var actionHandlers = make(map[string]ActionHandler)
+var actionRoles = make(map[string]Role)
+
+// register handler for actions with given name, buttons work only for users with the role,
+// it must be the role of the command which sends the buttons, since callback data can be forged,
+// params are names of action params passed in callback data of buttons created by NewButton, in this order
+func RegisterAction(name string, role Role, handler ActionHandler, params ...string) {
+	actionHandlers[name] = handler
+	actionRoles[name] = role
+	actionParams[name] = params
+}
+
+// text of alert for user who can't press buttons of the action
+func deniedAction(info *Info, name string) (string, bool) {
+	role := RoleOf(info)
+	if role >= actionRoles[name] {
+		return "", false
+	}
+	fmt.Printf("action %s denied for user %d\n", name, info.UserID())
+	if role >= RoleMember {
+		return info.T(i18n.AdminOnly), true
+	}
+	return info.T(i18n.PrivateBotShort), true
+}
+
+type callbackRegistry struct {
+	mutex   sync.Mutex
+	path    string
+	actions map[string]Action
+}
+
+var callbacks = newCallbackRegistry("")
+
+func newCallbackRegistry(path string) *callbackRegistry {
+	return &callbackRegistry{path: path, actions: make(map[string]Action)}
+}
+
+// load saved callbacks from file and remove expired ones every hour
+func LoadCallbacks(path string) error {
+	err := callbacks.load(path)
+	go func() {
+		for range time.Tick(time.Hour) {
+			callbacks.removeExpired(time.Now())
+		}
+	}()
+	return err
+}
+
+func callbackKey(chatID int64, messageID int) string {
+	return fmt.Sprintf("%d:%d", chatID, messageID)
+}
+
+func (registry *callbackRegistry) load(path string) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &registry.actions)
+}
+
+// remember action for the message
+func (registry *callbackRegistry) put(chatID int64, messageID int, action Action) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if action.Expires.IsZero() {
+		action.Expires = time.Now().Add(CallbackTTL)
+	}
+	registry.actions[callbackKey(chatID, messageID)] = action
+	registry.save()
+}
+
+// returns action for the message, false if it is unknown or expired
+func (registry *callbackRegistry) get(chatID int64, messageID int) (Action, bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	action, ok := registry.actions[callbackKey(chatID, messageID)]
+	if !ok || action.Expires.Before(time.Now()) {
+		return Action{}, false
+	}
+	return action, true
+}
+
+// remember action which is not bound to a message,
+// file is not rewritten while the saved action is fresh enough
+func (registry *callbackRegistry) putKey(key string, action Action) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	now := time.Now()
+	if saved, ok := registry.actions[key]; ok && saved.Expires.After(now.Add(CallbackTTL/2)) {
+		return
+	}
+	action.Expires = now.Add(CallbackTTL)
+	registry.actions[key] = action
+	registry.save()
+}
+
+func (registry *callbackRegistry) getKey(key string) (Action, bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	action, ok := registry.actions[key]
+	if !ok || action.Expires.Before(time.Now()) {
+		return Action{}, false
+	}
+	return action, true
+}
+
+func (registry *callbackRegistry) removeExpired(now time.Time) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	removed := 0
+	for key, action := range registry.actions {
+		if action.Expires.Before(now) {
+			delete(registry.actions, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		fmt.Println("removed expired callbacks:", removed)
+		registry.save()
+	}
+}
+
+// must be called with mutex locked, file is replaced atomically
+func (registry *callbackRegistry) save() {
+	if registry.path == "" {
+		return
+	}
+	data, err := json.Marshal(registry.actions)
+	if err != nil {
+		fmt.Println("error encode callbacks ", err)
+		return
+	}
+	tmpPath := registry.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		fmt.Println("error save callbacks ", err)
+		return
+	}
+	if err := os.Rename(tmpPath, registry.path); err != nil {
+		fmt.Println("error save callbacks ", err)
+	}
+}
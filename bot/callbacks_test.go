@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCallbackSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "callbacks.json")
+	registry := newCallbackRegistry(path)
+	registry.put(10, 20, *NewAction("topic_download", map[string]string{"topic": "123"}))
+
+	restarted := newCallbackRegistry("")
+	if err := restarted.load(path); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	action, ok := restarted.get(10, 20)
+	if !ok {
+		t.Fatalf("expected callback to be loaded")
+	}
+	if action.Name != "topic_download" || action.Params["topic"] != "123" {
+		t.Fatalf("not expected %v", action)
+	}
+}
+
+func TestCallbackKeyIncludesChat(t *testing.T) {
+	registry := newCallbackRegistry("")
+	registry.put(10, 20, Action{Name: "a"})
+	if _, ok := registry.get(11, 20); ok {
+		t.Fatalf("callback should not be found for other chat")
+	}
+}
+
+func TestExpiredCallbackIsNotReturned(t *testing.T) {
+	registry := newCallbackRegistry("")
+	registry.put(10, 20, Action{Name: "a", Expires: time.Now().Add(-time.Minute)})
+	if _, ok := registry.get(10, 20); ok {
+		t.Fatalf("expired callback should not be returned")
+	}
+}
+
+func TestRemoveExpiredCallbacks(t *testing.T) {
+	registry := newCallbackRegistry("")
+	registry.put(1, 1, Action{Name: "old", Expires: time.Now().Add(time.Hour)})
+	registry.put(1, 2, Action{Name: "new", Expires: time.Now().Add(3 * time.Hour)})
+	registry.removeExpired(time.Now().Add(2 * time.Hour))
+	if len(registry.actions) != 1 {
+		t.Fatalf("expected 1 callback left, got %d", len(registry.actions))
+	}
+	if _, ok := registry.actions[callbackKey(1, 2)]; !ok {
+		t.Fatalf("not expired callback was removed")
+	}
+}
+
+func TestLoadMissingCallbackFile(t *testing.T) {
+	registry := newCallbackRegistry("")
+	if err := registry.load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// abandoned conversations are removed after this time without answer
+var ConversationTimeout = 10 * time.Minute
+
+// Conversation is a multi-step flow in a chat, bot waits for free text answer in State
+type Conversation struct {
+	ChatID  int64
+	State   string
+	Data    map[string]string
+	origin  *Info
+	updated time.Time
+	key     conversationKey
+}
+
+// conversations of group members are separate, so one member does not answer question to another,
+// private chat has only one user and is keyed by chat alone
+type conversationKey struct {
+	chatID int64
+	userID int64
+}
+
+func keyOfConversation(chat *tgbotapi.Chat, user *tgbotapi.User) conversationKey {
+	var key conversationKey
+	if chat != nil {
+		key.chatID = chat.ID
+		if chat.IsPrivate() {
+			return key
+		}
+	}
+	if user != nil {
+		key.userID = user.ID
+	}
+	return key
+}
+
+// key of conversation with user who sent the message or pressed the button
+func (info *Info) conversationKey() conversationKey {
+	var chat *tgbotapi.Chat
+	if info.source != nil {
+		chat = info.source.Chat
+	}
+	return keyOfConversation(chat, info.from())
+}
+
+// handler of free text answer, it moves conversation with Next or Finish,
+// conversation stays in the same state if handler does nothing, e.g. for invalid answer
+type StateHandler func(info *Info, conversation *Conversation)
+
+var stateHandlers = make(map[string]StateHandler)
+var conversationsMutex sync.Mutex
+var conversations = make(map[conversationKey]*Conversation)
+var timeoutHandler Hanlder
+var startTimeoutsOnce sync.Once
+
+// register handler of answers in given state
+func RegisterState(state string, handler StateHandler) {
+	stateHandlers[state] = handler
+}
+
+// called with message which started conversation when it times out
+func OnConversationTimeout(handler Hanlder) {
+	timeoutHandler = handler
+}
+
+// start waiting for free text answer of the user in the chat, replaces previous conversation
+func StartConversation(info *Info, state string, data map[string]string) *Conversation {
+	startTimeoutsOnce.Do(func() {
+		go func() {
+			for range time.Tick(time.Minute) {
+				removeAbandonedConversations(time.Now())
+			}
+		}()
+	})
+
+	if data == nil {
+		data = make(map[string]string)
+	}
+	conversation := &Conversation{ChatID: info.ChatID(), State: state, Data: data, origin: info, updated: time.Now(), key: info.conversationKey()}
+	conversationsMutex.Lock()
+	defer conversationsMutex.Unlock()
+	conversations[conversation.key] = conversation
+	return conversation
+}
+
+// move conversation to the next state
+func (conversation *Conversation) Next(state string) {
+	conversationsMutex.Lock()
+	defer conversationsMutex.Unlock()
+	conversation.State = state
+	conversation.updated = time.Now()
+}
+
+// end conversation, next text messages go to usual handlers
+func (conversation *Conversation) Finish() {
+	conversationsMutex.Lock()
+	defer conversationsMutex.Unlock()
+	if conversations[conversation.key] == conversation {
+		delete(conversations, conversation.key)
+	}
+}
+
+// returns active conversation of the user in the chat
+func activeConversation(key conversationKey) (*Conversation, bool) {
+	conversationsMutex.Lock()
+	defer conversationsMutex.Unlock()
+	conversation, ok := conversations[key]
+	if !ok || time.Since(conversation.updated) > ConversationTimeout {
+		return nil, false
+	}
+	return conversation, true
+}
+
+// end conversation of the user in the chat, false if there was nothing to cancel
+func CancelConversation(info *Info) bool {
+	conversation, ok := activeConversation(info.conversationKey())
+	if ok {
+		conversation.Finish()
+	}
+	return ok
+}
+
+// pass text message to handler of current conversation state
+func ContinueConversation(info *Info) {
+	conversation, ok := activeConversation(info.conversationKey())
+	if !ok {
+		return
+	}
+	handler, ok := stateHandlers[conversation.State]
+	if !ok {
+		fmt.Println("no handler for conversation state ", conversation.State)
+		conversation.Finish()
+		return
+	}
+	handler(info, conversation)
+}
+
+func removeAbandonedConversations(now time.Time) {
+	conversationsMutex.Lock()
+	var abandoned []*Conversation
+	for key, conversation := range conversations {
+		if now.Sub(conversation.updated) > ConversationTimeout {
+			abandoned = append(abandoned, conversation)
+			delete(conversations, key)
+		}
+	}
+	conversationsMutex.Unlock()
+
+	for _, conversation := range abandoned {
+		fmt.Printf("conversation in chat %d timed out in state %s\n", conversation.ChatID, conversation.State)
+		if timeoutHandler != nil && conversation.origin != nil {
+			timeoutHandler(conversation.origin)
+		}
+	}
+}
+
+// ConversationMatcher matches text messages of users with active conversation in the chat
+type ConversationMatcher struct {
+}
+
+func NewConversationMatcher() *ConversationMatcher {
+	return &ConversationMatcher{}
+}
+
+func (matcher *ConversationMatcher) match(update *tgbotapi.Update) bool {
+	if len(update.Message.Entities) > 0 && update.Message.Entities[0].Type == "bot_command" {
+		return false
+	}
+
+	if update.Message.Document != nil || update.Message.Chat == nil {
+		return false
+	}
+
+	_, ok := activeConversation(keyOfConversation(update.Message.Chat, update.Message.From))
+	return ok
+}
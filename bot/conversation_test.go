@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func textInChat(chatID int64, text string) *tgbotapi.Update {
+	return textFrom(1, chatID, text)
+}
+
+func textFrom(userID int64, chatID int64, text string) *tgbotapi.Update {
+	return &tgbotapi.Update{Message: &tgbotapi.Message{Text: text, From: &tgbotapi.User{ID: userID}, Chat: &tgbotapi.Chat{ID: chatID}}}
+}
+
+func TestConversationMatcherOnlyInActiveChat(t *testing.T) {
+	StartConversation(messageFrom(1, 100), "ask_size", nil)
+	defer CancelConversation(messageFrom(1, 100))
+
+	matcher := NewConversationMatcher()
+	if !matcher.match(textInChat(100, "10 GB")) {
+		t.Fatalf("expected match in chat with conversation")
+	}
+	if matcher.match(textInChat(101, "10 GB")) {
+		t.Fatalf("not expected match in other chat")
+	}
+	command := textInChat(100, "/cancel")
+	command.Message.Entities = []tgbotapi.MessageEntity{{Offset: 0, Length: 7, Type: "bot_command"}}
+	if matcher.match(command) {
+		t.Fatalf("commands should not be matched")
+	}
+}
+
+func TestConversationIsAnsweredOnlyByItsUserInGroup(t *testing.T) {
+	answers := []string{}
+	RegisterState("ask_group", func(info *Info, conversation *Conversation) {
+		answers = append(answers, info.Text)
+		conversation.Finish()
+	})
+
+	StartConversation(messageFrom(1, -500), "ask_group", nil)
+	defer CancelConversation(messageFrom(1, -500))
+
+	matcher := NewConversationMatcher()
+	if matcher.match(textFrom(2, -500, "hello")) {
+		t.Fatalf("not expected match of other member")
+	}
+	other := messageFrom(2, -500)
+	other.Text = "hello"
+	ContinueConversation(other)
+	if CancelConversation(messageFrom(2, -500)) {
+		t.Fatalf("other member has no conversation to cancel")
+	}
+
+	if !matcher.match(textFrom(1, -500, "10 GB")) {
+		t.Fatalf("expected match of member who started conversation")
+	}
+	answer := messageFrom(1, -500)
+	answer.Text = "10 GB"
+	ContinueConversation(answer)
+	if len(answers) != 1 || answers[0] != "10 GB" {
+		t.Fatalf("not expected answers %v", answers)
+	}
+}
+
+func TestConversationInPrivateChatIsKeyedByChat(t *testing.T) {
+	private := &tgbotapi.Chat{ID: 600, Type: "private"}
+	if keyOfConversation(private, &tgbotapi.User{ID: 600}) != keyOfConversation(private, nil) {
+		t.Fatalf("private chat should be keyed by chat only")
+	}
+	group := &tgbotapi.Chat{ID: -600, Type: "group"}
+	if keyOfConversation(group, &tgbotapi.User{ID: 1}) == keyOfConversation(group, &tgbotapi.User{ID: 2}) {
+		t.Fatalf("group members should have separate conversations")
+	}
+}
+
+func TestConversationTransitions(t *testing.T) {
+	answers := []string{}
+	RegisterState("ask_size", func(info *Info, conversation *Conversation) {
+		answers = append(answers, "size:"+info.Text)
+		conversation.Data["size"] = info.Text
+		conversation.Next("ask_folder")
+	})
+	RegisterState("ask_folder", func(info *Info, conversation *Conversation) {
+		answers = append(answers, "folder:"+info.Text+":"+conversation.Data["size"])
+		conversation.Finish()
+	})
+
+	StartConversation(messageFrom(1, 200), "ask_size", nil)
+	answer := messageFrom(1, 200)
+	answer.Text = "10 GB"
+	ContinueConversation(answer)
+	answer.Text = "movies"
+	ContinueConversation(answer)
+
+	if len(answers) != 2 || answers[1] != "folder:movies:10 GB" {
+		t.Fatalf("not expected answers %v", answers)
+	}
+	if _, ok := activeConversation(messageFrom(1, 200).conversationKey()); ok {
+		t.Fatalf("conversation should be finished")
+	}
+}
+
+func TestCancelConversation(t *testing.T) {
+	StartConversation(messageFrom(1, 300), "ask_size", nil)
+	if !CancelConversation(messageFrom(1, 300)) {
+		t.Fatalf("expected conversation to be cancelled")
+	}
+	if CancelConversation(messageFrom(1, 300)) {
+		t.Fatalf("nothing should be cancelled second time")
+	}
+}
+
+func TestAbandonedConversationTimesOut(t *testing.T) {
+	timedOut := int64(0)
+	OnConversationTimeout(func(info *Info) {
+		timedOut = info.ChatID()
+	})
+	defer OnConversationTimeout(nil)
+
+	StartConversation(messageFrom(1, 400), "ask_size", nil)
+	removeAbandonedConversations(time.Now().Add(ConversationTimeout + time.Second))
+	if _, ok := activeConversation(messageFrom(1, 400).conversationKey()); ok {
+		t.Fatalf("conversation should be removed")
+	}
+	if timedOut != 400 {
+		t.Fatalf("timeout handler not called")
+	}
+}
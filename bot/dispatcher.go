@@ -0,0 +1,216 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// telegram limits: about 30 messages per second overall, 1 per second in a private chat
+// and 20 per minute in a group, see https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+const (
+	globalSendInterval  = time.Second / 30
+	privateSendInterval = time.Second
+	groupSendInterval   = time.Minute / 20
+)
+
+// warn in log when messages wait in a chat queue
+const queueDepthWarning = 20
+
+// queue of a chat is removed after it has nothing to send for this time,
+// it is much longer than send intervals, so limits of the chat are kept meanwhile
+const queueIdleTimeout = 10 * time.Minute
+
+// rateLimiter gives slots to send requests not more often than interval
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval}
+}
+
+// reserve the next slot and return how long to wait for it
+func (limiter *rateLimiter) reserve(now time.Time) time.Duration {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	if limiter.next.Before(now) {
+		limiter.next = now
+	}
+	delay := limiter.next.Sub(now)
+	limiter.next = limiter.next.Add(limiter.interval)
+	return delay
+}
+
+func (limiter *rateLimiter) wait() {
+	time.Sleep(limiter.reserve(time.Now()))
+}
+
+// messages of one chat, they are delivered in order by one goroutine
+type chatQueue struct {
+	messages  []OutMessage
+	running   bool
+	limiter   *rateLimiter
+	idleSince time.Time // when the last message was delivered
+}
+
+// dispatcher delivers messages of different chats independently, so a slow chat does not block others
+type dispatcher struct {
+	mutex           sync.Mutex
+	queues          map[int64]*chatQueue
+	global          *rateLimiter
+	privateInterval time.Duration
+	groupInterval   time.Duration
+	deliver         func(message OutMessage)
+	input           chan OutMessage // channel read by Sender
+	lastCleanup     time.Time
+	sent            int64
+	throttled       int64
+}
+
+// QueueStats shows how many messages wait to be sent
+type QueueStats struct {
+	Chats       int   // chats with queued messages
+	Queued      int   // messages in all queues
+	Longest     int   // messages in the longest queue
+	LongestChat int64 // chat with the longest queue
+	Sent        int64 // requests sent since start
+	Throttled   int64 // times telegram asked to retry later
+}
+
+var outgoing = newDispatcher(nil)
+
+// send without telegram limits, for FakeTransport
+func DisableRateLimits() {
+	outgoing.mutex.Lock()
+	defer outgoing.mutex.Unlock()
+	outgoing.global = newRateLimiter(0)
+	outgoing.privateInterval = 0
+	outgoing.groupInterval = 0
+	for _, queue := range outgoing.queues {
+		queue.limiter = newRateLimiter(0)
+	}
+}
+
+func newDispatcher(deliver func(message OutMessage)) *dispatcher {
+	return &dispatcher{
+		queues:          make(map[int64]*chatQueue),
+		global:          newRateLimiter(globalSendInterval),
+		privateInterval: privateSendInterval,
+		groupInterval:   groupSendInterval,
+		deliver:         deliver,
+	}
+}
+
+// add message to the queue of its chat
+func (d *dispatcher) enqueue(message OutMessage) {
+	chatID := message.OriginalMessage.ChatID()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if now := time.Now(); now.Sub(d.lastCleanup) > queueIdleTimeout {
+		d.lastCleanup = now
+		d.removeIdleQueues(now)
+	}
+
+	queue, ok := d.queues[chatID]
+	if !ok {
+		interval := d.privateInterval
+		if chatID < 0 {
+			interval = d.groupInterval
+		}
+		queue = &chatQueue{limiter: newRateLimiter(interval)}
+		d.queues[chatID] = queue
+	}
+
+	queue.messages = append(queue.messages, message)
+	if len(queue.messages) >= queueDepthWarning {
+		fmt.Printf("Warning: %d messages wait for chat %d\n", len(queue.messages), chatID)
+	}
+	if !queue.running {
+		queue.running = true
+		go d.run(queue)
+	}
+}
+
+// deliver messages of the queue one by one until it is empty
+func (d *dispatcher) run(queue *chatQueue) {
+	for {
+		d.mutex.Lock()
+		if len(queue.messages) == 0 {
+			queue.running = false
+			queue.idleSince = time.Now()
+			d.mutex.Unlock()
+			return
+		}
+		message := queue.messages[0]
+		queue.messages = queue.messages[1:]
+		d.mutex.Unlock()
+
+		d.deliver(message)
+	}
+}
+
+// forget queues of chats which got nothing for queueIdleTimeout, goroutine of such queue is already stopped,
+// mutex must be locked
+func (d *dispatcher) removeIdleQueues(now time.Time) {
+	for chatID, queue := range d.queues {
+		if !queue.running && len(queue.messages) == 0 && now.Sub(queue.idleSince) > queueIdleTimeout {
+			delete(d.queues, chatID)
+		}
+	}
+}
+
+// wait until request to the chat can be sent without hitting telegram limits
+func (d *dispatcher) waitForSlot(chatID int64) {
+	d.mutex.Lock()
+	queue, ok := d.queues[chatID]
+	d.mutex.Unlock()
+	if ok {
+		queue.limiter.wait()
+	}
+	d.global.wait()
+	atomic.AddInt64(&d.sent, 1)
+}
+
+func (d *dispatcher) stats() QueueStats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	result := QueueStats{Sent: atomic.LoadInt64(&d.sent), Throttled: atomic.LoadInt64(&d.throttled)}
+	for chatID, queue := range d.queues {
+		depth := len(queue.messages)
+		if depth == 0 {
+			continue
+		}
+		result.Chats++
+		result.Queued += depth
+		if depth > result.Longest {
+			result.Longest = depth
+			result.LongestChat = chatID
+		}
+	}
+	return result
+}
+
+// true if nothing waits in the input channel or queues and nothing is being sent
+func (d *dispatcher) idle() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.input) > 0 {
+		return false
+	}
+	for _, queue := range d.queues {
+		if queue.running || len(queue.messages) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// current state of outgoing queues
+func OutgoingStats() QueueStats {
+	return outgoing.stats()
+}
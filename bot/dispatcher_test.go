@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacesSlots(t *testing.T) {
+	limiter := newRateLimiter(time.Second)
+	now := time.Now()
+	if delay := limiter.reserve(now); delay != 0 {
+		t.Fatalf("first slot should be free, got %s", delay)
+	}
+	if delay := limiter.reserve(now); delay != time.Second {
+		t.Fatalf("expected 1s, got %s", delay)
+	}
+	if delay := limiter.reserve(now.Add(5 * time.Second)); delay != 0 {
+		t.Fatalf("slot after pause should be free, got %s", delay)
+	}
+}
+
+func TestDispatcherKeepsOrderInChat(t *testing.T) {
+	var mutex sync.Mutex
+	var delivered []string
+	done := make(chan bool)
+	d := newDispatcher(func(message OutMessage) {
+		mutex.Lock()
+		delivered = append(delivered, message.Text)
+		if len(delivered) == 3 {
+			done <- true
+		}
+		mutex.Unlock()
+	})
+
+	chat := messageFrom(1, 1)
+	for _, text := range []string{"one", "two", "three"} {
+		d.enqueue(OutMessage{OriginalMessage: chat, Text: text})
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("messages were not delivered")
+	}
+	if delivered[0] != "one" || delivered[1] != "two" || delivered[2] != "three" {
+		t.Fatalf("order is broken %v", delivered)
+	}
+}
+
+func TestSlowChatDoesNotBlockOthers(t *testing.T) {
+	blocked := make(chan bool)
+	delivered := make(chan int64, 1)
+	d := newDispatcher(func(message OutMessage) {
+		if message.OriginalMessage.ChatID() == 1 {
+			<-blocked
+		}
+		delivered <- message.OriginalMessage.ChatID()
+	})
+	defer close(blocked)
+
+	d.enqueue(OutMessage{OriginalMessage: messageFrom(1, 1), Text: "slow"})
+	d.enqueue(OutMessage{OriginalMessage: messageFrom(1, 1), Text: "waits"})
+	d.enqueue(OutMessage{OriginalMessage: messageFrom(2, 2), Text: "fast"})
+
+	select {
+	case chatID := <-delivered:
+		if chatID != 2 {
+			t.Fatalf("expected chat 2, got %d", chatID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("chat 2 is blocked by chat 1")
+	}
+
+	if stats := d.stats(); stats.Chats != 1 || stats.LongestChat != 1 {
+		t.Fatalf("not expected stats %+v", stats)
+	}
+}
+
+func TestIdleQueueIsRemoved(t *testing.T) {
+	blocked := make(chan bool)
+	delivered := make(chan int64, 2)
+	d := newDispatcher(func(message OutMessage) {
+		if message.OriginalMessage.ChatID() == 2 {
+			<-blocked
+		}
+		delivered <- message.OriginalMessage.ChatID()
+	})
+	defer close(blocked)
+
+	d.enqueue(OutMessage{OriginalMessage: messageFrom(1, 1), Text: "done"})
+	d.enqueue(OutMessage{OriginalMessage: messageFrom(2, 2), Text: "sending"})
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatalf("message was not delivered")
+	}
+	for !d.idleChat(1) {
+		time.Sleep(time.Millisecond)
+	}
+
+	d.mutex.Lock()
+	d.removeIdleQueues(time.Now())
+	if len(d.queues) != 2 {
+		t.Fatalf("queue which is idle for a short time should stay, %d queues", len(d.queues))
+	}
+	d.removeIdleQueues(time.Now().Add(queueIdleTimeout + time.Second))
+	_, idle := d.queues[1]
+	_, busy := d.queues[2]
+	d.mutex.Unlock()
+	if idle || !busy {
+		t.Fatalf("expected only queue of busy chat, idle %v busy %v", idle, busy)
+	}
+
+	// removed chat gets a new queue
+	d.enqueue(OutMessage{OriginalMessage: messageFrom(1, 1), Text: "again"})
+	select {
+	case chatID := <-delivered:
+		if chatID != 1 {
+			t.Fatalf("expected chat 1, got %d", chatID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("message after removal was not delivered")
+	}
+}
+
+// true if queue of the chat has nothing to send
+func (d *dispatcher) idleChat(chatID int64) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	queue, ok := d.queues[chatID]
+	return ok && !queue.running && len(queue.messages) == 0
+}
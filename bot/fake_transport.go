@@ -0,0 +1,277 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SentMessage is a request made by the bot to FakeTransport
+type SentMessage struct {
+	ChatID    int64
+	MessageID int
+	Text      string
+	Html      bool
+	Edit      bool   // text or keyboard of MessageID was edited
+	FileName  string // document was sent
+	ReplyTo   int
+	ThreadID  int // forum topic
+	Keyboard  *tgbotapi.InlineKeyboardMarkup
+}
+
+// CallbackAnswer is an answer to pressed button
+type CallbackAnswer struct {
+	QueryID string
+	Text    string
+	Alert   bool
+}
+
+// FakeTransport is an in-process telegram, updates are scripted by test
+// and everything the bot sends is recorded
+type FakeTransport struct {
+	mutex     sync.Mutex
+	updates   chan tgbotapi.Update
+	changed   chan struct{}
+	nextID    int
+	updateID  int
+	sent      []SentMessage
+	answers   []CallbackAnswer
+	requests  []tgbotapi.Chattable
+	endpoints []string
+	files     map[string]string
+	self      tgbotapi.User
+}
+
+// username of the fake bot, used in commands and mentions
+const FakeBotName = "fake_bot"
+
+func NewFakeTransport() *FakeTransport {
+	return &FakeTransport{
+		updates: make(chan tgbotapi.Update, 100),
+		changed: make(chan struct{}),
+		nextID:  1000,
+		files:   make(map[string]string),
+		self:    tgbotapi.User{ID: 1, IsBot: true, UserName: FakeBotName},
+	}
+}
+
+// must be called with mutex locked, wakes up everybody waiting for messages
+func (f *FakeTransport) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *FakeTransport) Send(message tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return f.SendToThread(message, 0)
+}
+
+func (f *FakeTransport) SendToThread(message tgbotapi.Chattable, threadID int) (tgbotapi.Message, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.nextID++
+	sent := SentMessage{MessageID: f.nextID, ThreadID: threadID}
+	switch m := message.(type) {
+	case tgbotapi.MessageConfig:
+		sent.ChatID = m.ChatID
+		sent.Text = m.Text
+		sent.Html = m.ParseMode == "HTML"
+		sent.ReplyTo = m.ReplyToMessageID
+		if keyboard, ok := m.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+			sent.Keyboard = &keyboard
+		}
+	case tgbotapi.DocumentConfig:
+		sent.ChatID = m.ChatID
+		sent.ReplyTo = m.ReplyToMessageID
+		if file, ok := m.File.(tgbotapi.FileReader); ok {
+			sent.FileName = file.Name
+		}
+	default:
+		return tgbotapi.Message{}, fmt.Errorf("fake transport can't send %T", message)
+	}
+
+	// telegram sends topic of the message with the button when it is pressed
+	if threadID != 0 {
+		threads.remember(sent.ChatID, sent.MessageID, threadID)
+	}
+	f.sent = append(f.sent, sent)
+	f.notify()
+	return tgbotapi.Message{MessageID: sent.MessageID, Chat: &tgbotapi.Chat{ID: sent.ChatID}, Text: sent.Text}, nil
+}
+
+func (f *FakeTransport) Edit(edit tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	sent := SentMessage{Edit: true}
+	switch m := edit.(type) {
+	case tgbotapi.EditMessageTextConfig:
+		sent.ChatID = m.ChatID
+		sent.MessageID = m.MessageID
+		sent.Text = m.Text
+		sent.Html = m.ParseMode == "HTML"
+		sent.Keyboard = m.ReplyMarkup
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		sent.ChatID = m.ChatID
+		sent.MessageID = m.MessageID
+		sent.Keyboard = m.ReplyMarkup
+	default:
+		return tgbotapi.Message{}, fmt.Errorf("fake transport can't edit %T", edit)
+	}
+
+	f.sent = append(f.sent, sent)
+	f.notify()
+	return tgbotapi.Message{MessageID: sent.MessageID, Chat: &tgbotapi.Chat{ID: sent.ChatID}, Text: sent.Text}, nil
+}
+
+func (f *FakeTransport) AnswerCallback(answer tgbotapi.CallbackConfig) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.answers = append(f.answers, CallbackAnswer{QueryID: answer.CallbackQueryID, Text: answer.Text, Alert: answer.ShowAlert})
+	f.notify()
+	return nil
+}
+
+// url of file registered by AddFile, file id itself if unknown
+func (f *FakeTransport) GetFileURL(fileID string) (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if url, ok := f.files[fileID]; ok {
+		return url, nil
+	}
+	return fileID, nil
+}
+
+func (f *FakeTransport) Updates(ctx context.Context) tgbotapi.UpdatesChannel {
+	return f.updates
+}
+
+func (f *FakeTransport) Request(request tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.requests = append(f.requests, request)
+	return &tgbotapi.APIResponse{Ok: true, Result: []byte("true")}, nil
+}
+
+func (f *FakeTransport) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.endpoints = append(f.endpoints, endpoint)
+	return &tgbotapi.APIResponse{Ok: true, Result: []byte("true")}, nil
+}
+
+func (f *FakeTransport) Self() tgbotapi.User {
+	return f.self
+}
+
+// stop receiving updates, handleUpdates returns
+func (f *FakeTransport) Close() {
+	close(f.updates)
+}
+
+// AddFile sets url returned for the file id
+func (f *FakeTransport) AddFile(fileID string, url string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.files[fileID] = url
+}
+
+// SendUpdate passes update to the bot as if it came from telegram
+func (f *FakeTransport) SendUpdate(update tgbotapi.Update) {
+	f.mutex.Lock()
+	f.updateID++
+	update.UpdateID = f.updateID
+	f.mutex.Unlock()
+	f.updates <- update
+}
+
+// SendText sends message from the user to the chat, text starting with / is a command
+func (f *FakeTransport) SendText(chatID int64, userID int64, text string) int {
+	return f.sendText(&tgbotapi.Chat{ID: chatID, Type: "private"}, userID, 0, text)
+}
+
+// SendGroupText sends message from the user to forum topic of supergroup, topic 0 is the general chat
+func (f *FakeTransport) SendGroupText(chatID int64, userID int64, threadID int, text string) int {
+	return f.sendText(&tgbotapi.Chat{ID: chatID, Type: "supergroup"}, userID, threadID, text)
+}
+
+func (f *FakeTransport) sendText(chat *tgbotapi.Chat, userID int64, threadID int, text string) int {
+	f.mutex.Lock()
+	f.nextID++
+	messageID := f.nextID
+	f.mutex.Unlock()
+
+	message := &tgbotapi.Message{
+		MessageID: messageID,
+		From:      &tgbotapi.User{ID: userID},
+		Chat:      chat,
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		length := len(strings.Fields(text)[0])
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}}
+	}
+	if threadID != 0 {
+		threads.remember(chat.ID, messageID, threadID)
+	}
+	f.SendUpdate(tgbotapi.Update{Message: message})
+	return messageID
+}
+
+// PressButton presses button with given data under the message sent by the bot
+func (f *FakeTransport) PressButton(chatID int64, userID int64, messageID int, data string) {
+	f.SendUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      fmt.Sprintf("query_%d_%s", messageID, data),
+		From:    &tgbotapi.User{ID: userID},
+		Message: &tgbotapi.Message{MessageID: messageID, Chat: &tgbotapi.Chat{ID: chatID}},
+		Data:    data,
+	}})
+}
+
+// Messages returns copy of everything sent or edited so far
+func (f *FakeTransport) Messages() []SentMessage {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]SentMessage{}, f.sent...)
+}
+
+// CallbackAnswers returns answers to pressed buttons
+func (f *FakeTransport) CallbackAnswers() []CallbackAnswer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]CallbackAnswer{}, f.answers...)
+}
+
+// Requests returns other requests made with Request
+func (f *FakeTransport) Requests() []tgbotapi.Chattable {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]tgbotapi.Chattable{}, f.requests...)
+}
+
+// WaitForMessages waits until at least count messages are sent or edited and returns all of them
+func (f *FakeTransport) WaitForMessages(count int, timeout time.Duration) ([]SentMessage, error) {
+	deadline := time.After(timeout)
+	for {
+		f.mutex.Lock()
+		if len(f.sent) >= count {
+			result := append([]SentMessage{}, f.sent...)
+			f.mutex.Unlock()
+			return result, nil
+		}
+		changed := f.changed
+		sent := len(f.sent)
+		f.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return nil, fmt.Errorf("expected %d messages, got %d", count, sent)
+		}
+	}
+}
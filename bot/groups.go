@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// prepare message for matching, command is stripped of @botname, mention is removed from text,
+// returns false if the message is not for the bot: command of another bot,
+// or group message which neither mentions the bot nor replies to it
+func addressedToBot(message *tgbotapi.Message, self tgbotapi.User) bool {
+	if message.IsCommand() {
+		length := message.Entities[0].Length
+		if length > len(message.Text) {
+			length = len(message.Text)
+		}
+		command := message.Text[:length]
+		at := strings.Index(command, "@")
+		if at < 0 {
+			return true
+		}
+		if !strings.EqualFold(command[at+1:], self.UserName) {
+			return false
+		}
+
+		// name of the bot is ascii, so bytes are the same as utf-16 units of entity offsets
+		cut := len(command) - at
+		message.Text = command[:at] + message.Text[length:]
+		message.Entities[0].Length = at
+		for i := 1; i < len(message.Entities); i++ {
+			message.Entities[i].Offset -= cut
+		}
+		return true
+	}
+
+	if message.Chat == nil || (!message.Chat.IsGroup() && !message.Chat.IsSuperGroup()) {
+		return true
+	}
+	if reply := message.ReplyToMessage; reply != nil && reply.From != nil && reply.From.ID == self.ID && self.ID != 0 {
+		return true
+	}
+	if self.UserName == "" {
+		return false
+	}
+
+	mention := regexp.MustCompile(`(?i)@` + regexp.QuoteMeta(self.UserName) + `\b`)
+	if message.Document != nil {
+		return mention.MatchString(message.Caption)
+	}
+	if !mention.MatchString(message.Text) {
+		return false
+	}
+	// entities keep offsets of the original text, only the command entity is used for matching
+	message.Text = strings.Join(strings.Fields(mention.ReplaceAllString(message.Text, "")), " ")
+	return message.Text != ""
+}
+
+// forum topic of received messages, the library does not parse message_thread_id
+type threadRegistry struct {
+	mutex   sync.Mutex
+	threads map[string]threadEntry
+}
+
+type threadEntry struct {
+	ThreadID int
+	Seen     time.Time
+}
+
+// old topics are forgotten when there are more messages
+const maxThreads = 10000
+
+var threads = &threadRegistry{threads: make(map[string]threadEntry)}
+
+func (registry *threadRegistry) remember(chatID int64, messageID int, threadID int) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	now := time.Now()
+	if len(registry.threads) >= maxThreads {
+		for key, entry := range registry.threads {
+			if entry.Seen.Before(now.Add(-CallbackTTL)) {
+				delete(registry.threads, key)
+			}
+		}
+	}
+	registry.threads[callbackKey(chatID, messageID)] = threadEntry{ThreadID: threadID, Seen: now}
+}
+
+// topic of the message, 0 if it is not in a forum topic
+func (registry *threadRegistry) get(chatID int64, messageID int) int {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	return registry.threads[callbackKey(chatID, messageID)].ThreadID
+}
+
+// fields of the message missing in the library
+type topicMessage struct {
+	MessageID       int  `json:"message_id"`
+	MessageThreadID int  `json:"message_thread_id"`
+	IsTopicMessage  bool `json:"is_topic_message"`
+	Chat            struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+type topicUpdate struct {
+	Message       *topicMessage `json:"message"`
+	CallbackQuery *struct {
+		Message *topicMessage `json:"message"`
+	} `json:"callback_query"`
+}
+
+// remember forum topic of the message or of the message with pressed button in update json
+func rememberTopics(data []byte) {
+	var update topicUpdate
+	if err := json.Unmarshal(data, &update); err != nil {
+		return
+	}
+	messages := []*topicMessage{update.Message}
+	if update.CallbackQuery != nil {
+		messages = append(messages, update.CallbackQuery.Message)
+	}
+	for _, message := range messages {
+		if message != nil && message.IsTopicMessage && message.MessageThreadID != 0 {
+			threads.remember(message.Chat.ID, message.MessageID, message.MessageThreadID)
+		}
+	}
+}
+
+// parse result of getUpdates, topics of messages are remembered
+func decodeUpdates(data json.RawMessage) ([]tgbotapi.Update, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	updates := make([]tgbotapi.Update, 0, len(raw))
+	for _, item := range raw {
+		var update tgbotapi.Update
+		if err := json.Unmarshal(item, &update); err != nil {
+			return nil, err
+		}
+		rememberTopics(item)
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// endpoint, params and files of message sent to forum topic,
+// the library can't set message_thread_id, so the request is made by hand
+func threadRequest(message tgbotapi.Chattable, threadID int) (string, tgbotapi.Params, []tgbotapi.RequestFile, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero("message_thread_id", threadID)
+	switch m := message.(type) {
+	case tgbotapi.MessageConfig:
+		params.AddNonZero64("chat_id", m.ChatID)
+		params["text"] = m.Text
+		params.AddNonEmpty("parse_mode", m.ParseMode)
+		params.AddBool("disable_web_page_preview", m.DisableWebPagePreview)
+		params.AddNonZero("reply_to_message_id", m.ReplyToMessageID)
+		params.AddBool("allow_sending_without_reply", m.AllowSendingWithoutReply)
+		err := params.AddInterface("reply_markup", m.ReplyMarkup)
+		return "sendMessage", params, nil, err
+	case tgbotapi.DocumentConfig:
+		params.AddNonZero64("chat_id", m.ChatID)
+		params.AddNonEmpty("caption", m.Caption)
+		params.AddNonEmpty("parse_mode", m.ParseMode)
+		params.AddNonZero("reply_to_message_id", m.ReplyToMessageID)
+		err := params.AddInterface("reply_markup", m.ReplyMarkup)
+		return "sendDocument", params, []tgbotapi.RequestFile{{Name: "document", Data: m.File}}, err
+	}
+	return "", nil, nil, fmt.Errorf("can't send %T to forum topic", message)
+}
@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var testBot = tgbotapi.User{ID: 99, IsBot: true, UserName: "OurBot"}
+
+func groupMessage(text string) *tgbotapi.Message {
+	message := &tgbotapi.Message{Text: text, Chat: &tgbotapi.Chat{ID: -100, Type: "supergroup"}}
+	if text != "" && text[0] == '/' {
+		length := len(text)
+		for i, c := range text {
+			if c == ' ' {
+				length = i
+				break
+			}
+		}
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Length: length}, {Type: "url", Offset: length + 1, Length: 5}}
+	}
+	return message
+}
+
+func TestAddressedToBot(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected bool
+		result   string
+	}{
+		{"/saved", true, "/saved"},
+		{"/saved@ourbot", true, "/saved"},
+		{"/search@OurBot matrix", true, "/search matrix"},
+		{"/saved@otherbot", false, "/saved@otherbot"},
+		{"matrix", false, "matrix"},
+		{"@OurBot matrix", true, "matrix"},
+		{"find matrix @ourbot please", true, "find matrix please"},
+		{"@OurBotFan matrix", false, "@OurBotFan matrix"},
+		{"@ourbot", false, ""},
+	}
+	for _, c := range cases {
+		message := groupMessage(c.text)
+		if ok := addressedToBot(message, testBot); ok != c.expected || message.Text != c.result {
+			t.Fatalf("%s: expected %v %q, got %v %q", c.text, c.expected, c.result, ok, message.Text)
+		}
+	}
+
+	message := groupMessage("/search@ourbot matrix")
+	addressedToBot(message, testBot)
+	if message.Entities[0].Length != len("/search") || message.Entities[1].Offset != len("/search ") {
+		t.Fatalf("entities were not moved %v", message.Entities)
+	}
+}
+
+func TestAddressedToBotReplyAndPrivate(t *testing.T) {
+	reply := groupMessage("matrix")
+	reply.ReplyToMessage = &tgbotapi.Message{From: &testBot}
+	if !addressedToBot(reply, testBot) || reply.Text != "matrix" {
+		t.Fatalf("reply to the bot was not accepted")
+	}
+
+	private := &tgbotapi.Message{Text: "matrix", Chat: &tgbotapi.Chat{ID: 1, Type: "private"}}
+	if !addressedToBot(private, testBot) {
+		t.Fatalf("private message was not accepted")
+	}
+
+	document := groupMessage("")
+	document.Document = &tgbotapi.Document{FileName: "a.torrent"}
+	if addressedToBot(document, testBot) {
+		t.Fatalf("document without mention was accepted")
+	}
+	document.Caption = "@ourbot"
+	if !addressedToBot(document, testBot) {
+		t.Fatalf("document with mention was not accepted")
+	}
+}
+
+func TestDecodeUpdatesRemembersTopics(t *testing.T) {
+	data := `[{"update_id": 1, "message": {"message_id": 10, "message_thread_id": 5, "is_topic_message": true, "chat": {"id": -200, "type": "supergroup"}, "text": "hi"}},
+		{"update_id": 2, "callback_query": {"id": "q", "from": {"id": 1}, "data": "x", "message": {"message_id": 11, "message_thread_id": 6, "is_topic_message": true, "chat": {"id": -200, "type": "supergroup"}}}},
+		{"update_id": 3, "message": {"message_id": 12, "message_thread_id": 10, "chat": {"id": -200, "type": "supergroup"}, "text": "reply thread"}}]`
+	updates, err := decodeUpdates([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 3 || updates[0].Message.Text != "hi" || updates[1].CallbackQuery.Data != "x" {
+		t.Fatalf("not expected updates %v", updates)
+	}
+	if threads.get(-200, 10) != 5 || threads.get(-200, 11) != 6 {
+		t.Fatalf("topics were not remembered")
+	}
+	if threads.get(-200, 12) != 0 {
+		t.Fatalf("reply thread of group without topics is not a topic")
+	}
+}
+
+func TestThreadRequest(t *testing.T) {
+	msg := tgbotapi.NewMessage(-200, "hello")
+	msg.ParseMode = "HTML"
+	msg.ReplyToMessageID = 10
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("a", "b")))
+	endpoint, params, files, err := threadRequest(msg, 5)
+	if err != nil || endpoint != "sendMessage" || len(files) != 0 {
+		t.Fatalf("not expected %s %v %v", endpoint, files, err)
+	}
+	if params["message_thread_id"] != "5" || params["chat_id"] != "-200" || params["text"] != "hello" || params["reply_to_message_id"] != "10" || params["reply_markup"] == "" {
+		t.Fatalf("not expected params %v", params)
+	}
+
+	document := tgbotapi.NewDocument(-200, tgbotapi.FileBytes{Name: "a.txt", Bytes: []byte("a")})
+	endpoint, params, files, err = threadRequest(document, 5)
+	if err != nil || endpoint != "sendDocument" || len(files) != 1 || files[0].Name != "document" || params["message_thread_id"] != "5" {
+		t.Fatalf("not expected %s %v %v %v", endpoint, params, files, err)
+	}
+
+	if _, _, _, err := threadRequest(tgbotapi.NewChatAction(-200, tgbotapi.ChatTyping), 5); err == nil {
+		t.Fatalf("expected error for not supported message")
+	}
+}
+
+func TestForumTopicOverFakeTransport(t *testing.T) {
+	resetRoutes()
+	fake, output := startFakeBot(t)
+
+	AddHandler(NewCommandMatcher("/hello"), func(message *Info) {
+		output <- OutMessage{OriginalMessage: message, Text: "Hi"}
+	})
+	AddHandler(NewTextMatcher(".*"), func(message *Info) {
+		output <- OutMessage{OriginalMessage: message, Text: "echo " + message.Text}
+	})
+
+	fake.SendGroupText(-300, 1, 0, "just talking")
+	commandID := fake.SendGroupText(-300, 1, 7, "/hello@"+FakeBotName)
+	messages, err := fake.WaitForMessages(1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messages[0].Text != "Hi" || messages[0].ThreadID != 7 || messages[0].ReplyTo != commandID {
+		t.Fatalf("not expected reply %+v", messages[0])
+	}
+
+	fake.SendGroupText(-300, 1, 7, "@"+FakeBotName+" matrix")
+	messages, err = fake.WaitForMessages(2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messages[1].Text != "echo matrix" || messages[1].ThreadID != 7 {
+		t.Fatalf("not expected reply %+v", messages[1])
+	}
+	if len(fake.Messages()) != 2 {
+		t.Fatalf("message without mention was answered %+v", fake.Messages())
+	}
+}
@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/telegram-command-reader/i18n"
+)
+
+// Visibility tells where the route is listed for users
+type Visibility int
+
+const (
+	Hidden        Visibility = iota
+	VisibleInHelp            // only in /help, for commands with parameters
+	VisibleInMenu            // in /help and in command menu of telegram
+)
+
+// telegram accepts only such command names in the menu
+var menuCommandRegexp = regexp.MustCompile(`^/[a-z0-9_]{1,32}$`)
+
+// show command in /help and telegram menu, command with parameters like /download_<id> is shown only in /help
+func (route *Route) Describe(command string, description i18n.Key) *Route {
+	route.Usage = command
+	route.Description = description
+	route.Visibility = VisibleInMenu
+	if !menuCommandRegexp.MatchString(command) {
+		route.Visibility = VisibleInHelp
+	}
+	return route
+}
+
+// routes visible for the role in registration order
+func visibleRoutes(role Role, visibility Visibility) []*Route {
+	var result []*Route
+	for _, route := range routes {
+		if route.Visibility >= visibility && route.Usage != "" && role >= route.Role {
+			result = append(result, route)
+		}
+	}
+	return result
+}
+
+// list of commands available for the user who sent the message
+func HelpText(info *Info) string {
+	var lines []string
+	for _, route := range visibleRoutes(RoleOf(info), VisibleInHelp) {
+		lines = append(lines, route.Usage+" - "+info.T(route.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func menuCommands(role Role, lang string) []tgbotapi.BotCommand {
+	var commands []tgbotapi.BotCommand
+	for _, route := range visibleRoutes(role, VisibleInMenu) {
+		commands = append(commands, tgbotapi.BotCommand{Command: route.Usage[1:], Description: i18n.T(lang, route.Description)})
+	}
+	return commands
+}
+
+// set command menu in every supported language, everybody sees guest commands,
+// users and chats from access list see commands of their roles
+func PublishCommands() error {
+	accessMutex.RLock()
+	scopes := map[int64]Role{}
+	for chatID, role := range chatRoles {
+		scopes[chatID] = role
+	}
+	for userID, role := range userRoles {
+		// private chat has id of the user
+		if role > scopes[userID] {
+			scopes[userID] = role
+		}
+	}
+	accessMutex.RUnlock()
+
+	var requests []tgbotapi.SetMyCommandsConfig
+	for _, lang := range append([]string{""}, i18n.Languages()...) {
+		translation := lang
+		if lang == "" {
+			translation = i18n.DefaultLanguage
+		}
+		requests = append(requests, tgbotapi.NewSetMyCommandsWithScopeAndLanguage(tgbotapi.NewBotCommandScopeDefault(), lang, menuCommands(RoleGuest, translation)...))
+		for chatID, role := range scopes {
+			if role <= RoleGuest {
+				continue
+			}
+			requests = append(requests, tgbotapi.NewSetMyCommandsWithScopeAndLanguage(tgbotapi.NewBotCommandScopeChat(chatID), lang, menuCommands(role, translation)...))
+		}
+	}
+
+	var failed []string
+	for _, request := range requests {
+		if _, err := getTransport().Request(request); err != nil {
+			failed = append(failed, fmt.Sprintf("scope %s %d: %v", request.Scope.Type, request.Scope.ChatID, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("set commands: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/telegram-command-reader/i18n"
+)
+
+func registerHelpRoutes() {
+	resetRoutes()
+	AddHandler(NewCommandMatcher("/help"), func(message *Info) {}).Describe("/help", i18n.HelpHelp).Requires(RoleGuest)
+	AddHandler(NewCommandMatcher("/stats"), func(message *Info) {}).Describe("/stats", i18n.HelpStats).Requires(RoleAdmin)
+	AddHandler(NewCommandMatcher("/[0-9]+"), func(message *Info) {}).Describe("/<topic id>", i18n.HelpTopic)
+	AddHandler(NewCommandMatcher("/secret"), func(message *Info) {})
+}
+
+func TestHelpTextDependsOnRole(t *testing.T) {
+	registerHelpRoutes()
+	SetAccessList(map[int64]Role{1: RoleGuest, 2: RoleAdmin}, map[int64]Role{})
+	defer SetAccessList(map[int64]Role{}, map[int64]Role{})
+
+	guest := HelpText(messageFrom(1, 1))
+	if guest != "/help - "+i18n.T(i18n.DefaultLanguage, i18n.HelpHelp) {
+		t.Fatalf("not expected help for guest %q", guest)
+	}
+
+	admin := HelpText(messageFrom(2, 2))
+	if !strings.Contains(admin, "/stats") || !strings.Contains(admin, "/<topic id>") || strings.Contains(admin, "/secret") {
+		t.Fatalf("not expected help for admin %q", admin)
+	}
+}
+
+func TestMenuSkipsCommandsWithParameters(t *testing.T) {
+	registerHelpRoutes()
+	commands := menuCommands(RoleAdmin, i18n.English)
+	if len(commands) != 2 || commands[0].Command != "help" || commands[1].Command != "stats" {
+		t.Fatalf("not expected commands %+v", commands)
+	}
+	if commands[0].Description != "list of commands" {
+		t.Fatalf("description is not translated %q", commands[0].Description)
+	}
+}
+
+func TestPublishCommandsScopedByRole(t *testing.T) {
+	registerHelpRoutes()
+	SetAccessList(map[int64]Role{1: RoleGuest, 2: RoleAdmin}, map[int64]Role{-100: RoleMember})
+	defer SetAccessList(map[int64]Role{}, map[int64]Role{})
+	fake := NewFakeTransport()
+	SetTransport(fake)
+	defer SetTransport(nil)
+
+	if err := PublishCommands(); err != nil {
+		t.Fatal(err)
+	}
+
+	scopes := map[string]int{}
+	for _, request := range fake.Requests() {
+		config := request.(tgbotapi.SetMyCommandsConfig)
+		if config.LanguageCode != "" {
+			continue
+		}
+		scopes[config.Scope.Type]++
+		if config.Scope.Type == "chat" && config.Scope.ChatID == 2 && len(config.Commands) != 2 {
+			t.Fatalf("admin should see stats %+v", config.Commands)
+		}
+		if config.Scope.Type == "default" && len(config.Commands) != 1 {
+			t.Fatalf("everybody should see only guest commands %+v", config.Commands)
+		}
+	}
+	// guest uses default scope, admin and the group get own scopes
+	if scopes["default"] != 1 || scopes["chat"] != 2 {
+		t.Fatalf("not expected scopes %v", scopes)
+	}
+	if len(fake.Requests()) != 3*(len(i18n.Languages())+1) {
+		t.Fatalf("expected requests for every language, got %d", len(fake.Requests()))
+	}
+}
@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/telegram-command-reader/i18n"
+)
+
+// how long telegram may cache answer to the same inline query, in seconds
+const inlineCacheTime = 60
+
+// telegram shows not more than 50 results
+const maxInlineResults = 50
+
+// InlineArticle is a result of inline query, Text is posted to the chat when the result is chosen
+type InlineArticle struct {
+	Title          string
+	Description    string
+	Text           string
+	Html           bool
+	InlineKeyboard *tgbotapi.InlineKeyboardMarkup // buttons must be created by NewButton, posted message has no chat
+}
+
+// InlineHandler returns results for info.Text typed after @bot in any chat
+type InlineHandler func(info *Info) []InlineArticle
+
+var inlineHandler InlineHandler
+
+// searching is allowed for guests, buttons of posted messages need members since they change the server
+var inlineRoute = &Route{Name: "inline", Role: RoleGuest}
+var inlineCallbackRoute = &Route{Name: "inline_callback", Role: RoleMember}
+
+// set handler of inline queries, inline mode must be enabled for the bot in @BotFather
+func HandleInlineQueries(handler InlineHandler) {
+	inlineHandler = handler
+}
+
+// answer inline query with results of the handler, unknown users get nothing
+func handleInlineQuery(query *tgbotapi.InlineQuery) {
+	info := &Info{Text: strings.TrimSpace(query.Query), inline: query}
+	if inlineHandler == nil || info.Text == "" {
+		answerInlineQuery(query.ID, nil)
+		return
+	}
+	if RoleOf(info) < inlineRoute.Role {
+		fmt.Printf("inline query denied for user %d\n", info.UserID())
+		answerInlineQuery(query.ID, nil)
+		return
+	}
+
+	startHandler(inlineRoute, func(info *Info) {
+		articles := inlineHandler(info)
+		if len(articles) > maxInlineResults {
+			articles = articles[:maxInlineResults]
+		}
+		answerInlineQuery(query.ID, inlineResults(articles))
+	}, info)
+}
+
+func answerInlineQuery(queryID string, results []interface{}) {
+	if results == nil {
+		results = []interface{}{}
+	}
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       results,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+	}
+	if _, err := getTransport().Request(answer); err != nil {
+		fmt.Println("error answer inline query ", err)
+	}
+}
+
+// convert articles to telegram results
+func inlineResults(articles []InlineArticle) []interface{} {
+	var results []interface{}
+	for i, article := range articles {
+		result := tgbotapi.NewInlineQueryResultArticle(strconv.Itoa(i), article.Title, article.Text)
+		result.Description = article.Description
+		if article.Html {
+			result.InputMessageContent = tgbotapi.InputTextMessageContent{Text: article.Text, ParseMode: "HTML"}
+		}
+		result.ReplyMarkup = article.InlineKeyboard
+		results = append(results, result)
+	}
+	return results
+}
+
+// button of message posted via inline mode was pressed, such message has no chat,
+// so replies go to private chat with the user who pressed the button
+func handleInlineCallback(query *tgbotapi.CallbackQuery) {
+	action, value, ok := decodePayload(query.Data)
+	var handler ActionHandler
+	if ok {
+		handler, ok = actionHandlers[action.Name]
+	}
+
+	presser := &Info{callback: query}
+	answer := tgbotapi.NewCallback(query.ID, presser.T(i18n.SeePrivateChat))
+	if !ok {
+		fmt.Println("expired inline callback ", query.Data)
+		answer = tgbotapi.NewCallbackWithAlert(query.ID, presser.T(i18n.MenuExpired))
+	} else if RoleOf(presser) < inlineCallbackRoute.Role {
+		answer = tgbotapi.NewCallbackWithAlert(query.ID, presser.T(i18n.PrivateBotShort))
+		ok = false
+	} else if text, denied := deniedAction(presser, action.Name); denied {
+		answer = tgbotapi.NewCallbackWithAlert(query.ID, text)
+		ok = false
+	}
+	if err := getTransport().AnswerCallback(answer); err != nil {
+		fmt.Println("error answer callback ", err)
+	}
+
+	if ok {
+		startHandler(inlineCallbackRoute, func(info *Info) {
+			handler(info, action.Params)
+		}, &Info{
+			Text:     value,
+			source:   &tgbotapi.Message{From: query.From, Chat: &tgbotapi.Chat{ID: query.From.ID, Type: "private"}},
+			callback: query,
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package bot
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestInlineResultsKeepKeyboard(t *testing.T) {
+	RegisterAction("inline_download", RoleMember, func(info *Info, params map[string]string) {}, "topic")
+	keyboard := InlineDownloadKeyboard("ru", NewAction("inline_download", map[string]string{"topic": "42"}))
+	results := inlineResults([]InlineArticle{{Title: "Matrix", Text: "<b>Matrix</b>", Html: true, InlineKeyboard: &keyboard}})
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+
+	article := results[0].(tgbotapi.InlineQueryResultArticle)
+	action, value, ok := decodePayload(*article.ReplyMarkup.InlineKeyboard[0][0].CallbackData)
+	if !ok || action.Name != "inline_download" || action.Params["topic"] != "42" || value != DownloadActionServer {
+		t.Fatalf("not expected payload %v %s %v", action, value, ok)
+	}
+	if content := article.InputMessageContent.(tgbotapi.InputTextMessageContent); content.ParseMode != "HTML" {
+		t.Fatalf("html is not set")
+	}
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// recover from panic in handler and report it, so one broken handler does not stop the bot
+func RecoverMiddleware(onPanic func(info *Info, recovered interface{}, stack []byte)) Middleware {
+	return func(route *Route, next Hanlder) Hanlder {
+		return func(info *Info) {
+			defer func() {
+				if r := recover(); r != nil {
+					stack := debug.Stack()
+					fmt.Printf("panic in route %s: %v\n%s\n", route.Name, r, stack)
+					if onPanic != nil {
+						onPanic(info, r, stack)
+					}
+				}
+			}()
+			next(info)
+		}
+	}
+}
+
+// print every handled message
+func LoggingMiddleware(route *Route, next Hanlder) Hanlder {
+	return func(info *Info) {
+		fmt.Printf("route %s, chat %d: %s\n", route.Name, info.ChatID(), info.Text)
+		next(info)
+	}
+}
+
+// print how long handler was running
+func TimingMiddleware(route *Route, next Hanlder) Hanlder {
+	return func(info *Info) {
+		start := time.Now()
+		next(info)
+		fmt.Printf("route %s took %s\n", route.Name, time.Since(start))
+	}
+}
+
+// call handler only if allowed returns true, otherwise call denied
+func AuthMiddleware(allowed func(info *Info, route *Route) bool, denied Hanlder) Middleware {
+	return func(route *Route, next Hanlder) Hanlder {
+		return func(info *Info) {
+			if !allowed(info, route) {
+				fmt.Printf("route %s denied for user %d in chat %d\n", route.Name, info.UserID(), info.ChatID())
+				if denied != nil {
+					denied(info)
+				}
+				return
+			}
+			next(info)
+		}
+	}
+}
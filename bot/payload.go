@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// telegram limit of callback_data in bytes
+const maxCallbackData = 64
+
+// callback_data of version 1 is "1|action|button|param1|param2..." with params in order of RegisterAction,
+// payloads which do not fit into the limit are saved and passed as "1~key"
+const (
+	payloadVersion  = "1"
+	payloadSep      = "|"
+	payloadOverflow = "~"
+)
+
+// name of the param with value of pressed button in overflow store
+const buttonParam = "_button"
+
+var payloadEscaper = strings.NewReplacer("%", "%25", payloadSep, "%7C")
+var payloadUnescaper = strings.NewReplacer("%7C", payloadSep, "%25", "%")
+
+// names of params passed in callback data for every action, in order
+var actionParams = make(map[string][]string)
+
+// NewButton returns button which runs the action, value is info.Text in the action handler,
+// everything needed to run the action is in the button itself
+func NewButton(text string, action *Action, value string) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(text, encodePayload(*action, value))
+}
+
+// callback data for the action and pressed button value
+func encodePayload(action Action, value string) string {
+	names, complete := actionParams[action.Name]
+	for name := range action.Params {
+		if !containsString(names, name) {
+			complete = false
+		}
+	}
+	if complete {
+		parts := []string{payloadVersion, payloadEscaper.Replace(action.Name), payloadEscaper.Replace(value)}
+		for _, name := range names {
+			parts = append(parts, payloadEscaper.Replace(action.Params[name]))
+		}
+		if data := strings.Join(parts, payloadSep); len(data) <= maxCallbackData {
+			return data
+		}
+	}
+
+	// too long or unknown params, keep the payload on server
+	params := map[string]string{buttonParam: value}
+	for name, param := range action.Params {
+		params[name] = param
+	}
+	stored := Action{Name: action.Name, Params: params}
+	key := payloadKey(stored)
+	callbacks.putKey(overflowKey(key), stored)
+	return payloadVersion + payloadOverflow + key
+}
+
+// returns action and value of the pressed button, false if data is not a payload or it expired
+func decodePayload(data string) (Action, string, bool) {
+	if strings.HasPrefix(data, payloadVersion+payloadOverflow) {
+		stored, ok := callbacks.getKey(overflowKey(data[len(payloadVersion+payloadOverflow):]))
+		if !ok {
+			return Action{}, "", false
+		}
+		params := make(map[string]string)
+		for name, param := range stored.Params {
+			params[name] = param
+		}
+		value := params[buttonParam]
+		delete(params, buttonParam)
+		return Action{Name: stored.Name, Params: params}, value, true
+	}
+
+	if !strings.HasPrefix(data, payloadVersion+payloadSep) {
+		return Action{}, "", false
+	}
+	parts := strings.Split(data, payloadSep)
+	if len(parts) < 3 {
+		return Action{}, "", false
+	}
+	name := payloadUnescaper.Replace(parts[1])
+	names, ok := actionParams[name]
+	if !ok || len(parts)-3 != len(names) {
+		return Action{}, "", false
+	}
+	params := make(map[string]string)
+	for i, param := range names {
+		if value := payloadUnescaper.Replace(parts[3+i]); value != "" {
+			params[param] = value
+		}
+	}
+	return Action{Name: name, Params: params}, payloadUnescaper.Replace(parts[2]), true
+}
+
+func overflowKey(key string) string {
+	return "payload:" + key
+}
+
+// same payload gets the same key, so repeated keyboards do not grow the store
+func payloadKey(action Action) string {
+	var names []string
+	for name := range action.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := fnv.New64a()
+	hash.Write([]byte(action.Name))
+	for _, name := range names {
+		hash.Write([]byte("\x00" + name + "=" + action.Params[name]))
+	}
+	return strconv.FormatUint(hash.Sum64(), 36)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPayloadRoundTrip(t *testing.T) {
+	RegisterAction("payload_test", RoleMember, func(info *Info, params map[string]string) {}, "topic", "title")
+
+	data := encodePayload(*NewAction("payload_test", map[string]string{"topic": "42", "title": "a|b%c"}), "server")
+	if !strings.HasPrefix(data, "1|payload_test|server|42|") || len(data) > maxCallbackData {
+		t.Fatalf("not expected data %s", data)
+	}
+
+	action, value, ok := decodePayload(data)
+	if !ok || action.Name != "payload_test" || value != "server" {
+		t.Fatalf("not expected %v %s %v", action, value, ok)
+	}
+	if action.Params["topic"] != "42" || action.Params["title"] != "a|b%c" {
+		t.Fatalf("params were not restored %v", action.Params)
+	}
+}
+
+func TestPayloadOmitsEmptyParams(t *testing.T) {
+	RegisterAction("payload_empty", RoleMember, func(info *Info, params map[string]string) {}, "query", "category")
+
+	action, _, ok := decodePayload(encodePayload(*NewAction("payload_empty", map[string]string{"query": "matrix"}), ""))
+	if !ok || len(action.Params) != 1 || action.Params["query"] != "matrix" {
+		t.Fatalf("not expected %v %v", action, ok)
+	}
+}
+
+func TestPayloadOverflow(t *testing.T) {
+	RegisterAction("payload_long", RoleMember, func(info *Info, params map[string]string) {}, "magnet")
+
+	magnet := "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=" + strings.Repeat("x", 100)
+	long := *NewAction("payload_long", map[string]string{"magnet": magnet})
+	unknown := *NewAction("payload_long", map[string]string{"other": "1"})
+	for _, source := range []Action{long, unknown} {
+		data := encodePayload(source, "yes")
+		if !strings.HasPrefix(data, "1~") || len(data) > maxCallbackData {
+			t.Fatalf("not expected data %s", data)
+		}
+		if data != encodePayload(source, "yes") {
+			t.Fatalf("same payload has different keys")
+		}
+
+		action, value, ok := decodePayload(data)
+		if !ok || action.Name != "payload_long" || value != "yes" || len(action.Params) != len(source.Params) {
+			t.Fatalf("not expected %v %s %v", action, value, ok)
+		}
+		for name, param := range source.Params {
+			if action.Params[name] != param {
+				t.Fatalf("param %s was not restored %v", name, action.Params)
+			}
+		}
+	}
+}
+
+func TestDecodeNotPayload(t *testing.T) {
+	RegisterAction("payload_count", RoleMember, func(info *Info, params map[string]string) {}, "one")
+
+	for _, data := range []string{MessageMore, "1~unknown", "1|not_registered|x", "1|payload_count|x", "1|payload_count|x|1|2"} {
+		if _, _, ok := decodePayload(data); ok {
+			t.Fatalf("%s decoded as payload", data)
+		}
+	}
+}
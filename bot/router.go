@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/telegram-command-reader/i18n"
+)
+
+// route priorities, routes with higher priority are matched first
+const (
+	PriorityHigh     = 100
+	PriorityDefault  = 0
+	PriorityFallback = -100
+)
+
+// Route is a matcher-handler pair registered with AddHandler
+type Route struct {
+	Name        string
+	Priority    int
+	Role        Role       // minimal role required to run the handler
+	Usage       string     // how to call the route, like /saved
+	Description i18n.Key   // shown in /help and command menu
+	Visibility  Visibility // where the route is listed
+	matcher     Matcher
+	handler     Hanlder
+	order       int
+}
+
+// Middleware wraps handler of the route, first added middleware is the outermost one
+type Middleware func(route *Route, next Hanlder) Hanlder
+
+var routes []*Route
+var middlewares []Middleware
+
+// callbackRoute is used to run inline keyboard callbacks through the same middleware chain,
+// role of the action is checked before, see RegisterAction
+var callbackRoute = &Route{Name: "callback", Role: RoleGuest}
+
+// add handler to list, routes with the same priority are matched in order of registration
+func AddHandler(matcher Matcher, handler Hanlder) *Route {
+	route := &Route{
+		Name:     fmt.Sprintf("route_%d", len(routes)),
+		Priority: PriorityDefault,
+		Role:     RoleMember,
+		matcher:  matcher,
+		handler:  handler,
+		order:    len(routes),
+	}
+	routes = append(routes, route)
+	return route
+}
+
+// set name of the route, used in logs
+func (route *Route) Named(name string) *Route {
+	route.Name = name
+	return route
+}
+
+func (route *Route) WithPriority(priority int) *Route {
+	route.Priority = priority
+	return route
+}
+
+// add middlewares to the chain, they wrap every handler
+func Use(middleware ...Middleware) {
+	middlewares = append(middlewares, middleware...)
+}
+
+// returns routes ordered by priority, then by registration order
+func orderedRoutes() []*Route {
+	result := make([]*Route, len(routes))
+	copy(result, routes)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Priority != result[j].Priority {
+			return result[i].Priority > result[j].Priority
+		}
+		return result[i].order < result[j].order
+	})
+	return result
+}
+
+// wrap handler with all middlewares and call it
+func dispatch(route *Route, handler Hanlder, info *Info) {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](route, handler)
+	}
+	handler(info)
+}
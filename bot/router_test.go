@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func resetRoutes() {
+	routes = nil
+	middlewares = nil
+}
+
+func TestFallbackRouteMatchedLast(t *testing.T) {
+	resetRoutes()
+	AddHandler(NewTextMatcher(".*"), func(message *Info) {}).Named("search").WithPriority(PriorityFallback)
+	AddHandler(NewTextMatcher("magnet:.*"), func(message *Info) {}).Named("magnet")
+	update := &tgbotapi.Update{Message: &tgbotapi.Message{Text: "magnet:?xt=urn:btih:abc"}}
+	for i := 0; i < 20; i++ {
+		route, ok := findHandlerForUpdate(update)
+		if !ok || route.Name != "magnet" {
+			t.Fatalf("expected magnet route, got %v", route)
+		}
+	}
+}
+
+func TestSamePriorityMatchedInRegistrationOrder(t *testing.T) {
+	resetRoutes()
+	AddHandler(NewTextMatcher("a.*"), func(message *Info) {}).Named("first")
+	AddHandler(NewTextMatcher(".*"), func(message *Info) {}).Named("second")
+	route, ok := findHandlerForUpdate(&tgbotapi.Update{Message: &tgbotapi.Message{Text: "abc"}})
+	if !ok || route.Name != "first" {
+		t.Fatalf("expected first route, got %v", route)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	resetRoutes()
+	calls := []string{}
+	record := func(name string) Middleware {
+		return func(route *Route, next Hanlder) Hanlder {
+			return func(info *Info) {
+				calls = append(calls, name+":"+route.Name)
+				next(info)
+			}
+		}
+	}
+	Use(record("outer"), record("inner"))
+	route := AddHandler(NewTextMatcher(".*"), func(message *Info) {
+		calls = append(calls, "handler")
+	}).Named("test")
+	dispatch(route, route.handler, &Info{Text: "text"})
+	actual := strings.Join(calls, ",")
+	if actual != "outer:test,inner:test,handler" {
+		t.Fatalf("unexpected call order %s", actual)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	resetRoutes()
+	var recovered interface{}
+	Use(RecoverMiddleware(func(info *Info, r interface{}, stack []byte) {
+		recovered = r
+	}))
+	route := AddHandler(NewTextMatcher(".*"), func(message *Info) {
+		panic("boom")
+	})
+	dispatch(route, route.handler, &Info{Text: "text"})
+	if recovered != "boom" {
+		t.Fatalf("expected panic to be recovered, got %v", recovered)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	resetRoutes()
+	called := false
+	denied := false
+	Use(AuthMiddleware(func(info *Info, route *Route) bool {
+		return route.Name != "secret"
+	}, func(info *Info) {
+		denied = true
+	}))
+	route := AddHandler(NewTextMatcher(".*"), func(message *Info) {
+		called = true
+	}).Named("secret")
+	dispatch(route, route.handler, &Info{Text: "text"})
+	if called || !denied {
+		t.Fatalf("expected handler to be denied")
+	}
+}
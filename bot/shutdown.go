@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// context of work started by messages, cancelled when the bot has no more time to finish it
+var baseContext = context.Background()
+
+// number of handlers which are running now
+var runningHandlers int64
+
+// how often Flush and WaitForHandlers check if the work is done
+const drainInterval = 50 * time.Millisecond
+
+// set context of handlers, must be called before the bot is started
+func SetContext(ctx context.Context) {
+	baseContext = ctx
+}
+
+// context of work started by the message, it is cancelled when the bot stops
+func (info *Info) Context() context.Context {
+	return baseContext
+}
+
+// run handler of the route in own goroutine, the bot waits for it on shutdown
+func startHandler(route *Route, handler Hanlder, info *Info) {
+	atomic.AddInt64(&runningHandlers, 1)
+	go func() {
+		defer atomic.AddInt64(&runningHandlers, -1)
+		dispatch(route, handler, info)
+	}()
+}
+
+// wait until running handlers return, error if ctx is done earlier
+func WaitForHandlers(ctx context.Context) error {
+	for atomic.LoadInt64(&runningHandlers) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(drainInterval):
+		}
+	}
+	return nil
+}
+
+// wait until messages passed to Sender are sent, error if ctx is done earlier,
+// Sender may hold a received message for a moment before queueing it, so idle is checked twice
+func Flush(ctx context.Context) error {
+	for checks := 0; checks < 2; {
+		if outgoing.idle() {
+			checks++
+		} else {
+			checks = 0
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(drainInterval):
+		}
+	}
+	return nil
+}
+
+// MessageRef is enough to reply to the message after restart
+type MessageRef struct {
+	ChatID    int64  `json:"chat_id"`
+	MessageID int    `json:"message_id"`
+	ThreadID  int    `json:"thread_id,omitempty"`
+	UserID    int64  `json:"user_id"`
+	Language  string `json:"language,omitempty"`
+}
+
+// reference to the message which can be saved
+func (info *Info) Ref() MessageRef {
+	return MessageRef{
+		ChatID:    info.ChatID(),
+		MessageID: info.MessageID(),
+		ThreadID:  info.ThreadID(),
+		UserID:    info.UserID(),
+		Language:  info.Language(),
+	}
+}
+
+// info of saved message, replies go to its chat and topic
+func RestoreInfo(ref MessageRef) *Info {
+	if ref.ThreadID != 0 {
+		threads.remember(ref.ChatID, ref.MessageID, ref.ThreadID)
+	}
+	return &Info{source: &tgbotapi.Message{
+		MessageID: ref.MessageID,
+		From:      &tgbotapi.User{ID: ref.UserID, LanguageCode: ref.Language},
+		Chat:      &tgbotapi.Chat{ID: ref.ChatID},
+	}}
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitForHandlersAndFlush(t *testing.T) {
+	resetRoutes()
+	fake, output := startFakeBot(t)
+
+	release := make(chan struct{})
+	AddHandler(NewCommandMatcher("/slow"), func(message *Info) {
+		<-release
+		output <- OutMessage{OriginalMessage: message, Text: "done"}
+	})
+
+	fake.SendText(1, 1, "/slow")
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := WaitForHandlers(ctx); err == nil {
+		t.Fatalf("handler is still running")
+	}
+
+	close(release)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := WaitForHandlers(ctx); err != nil {
+		t.Fatalf("handler should return: %v", err)
+	}
+	if err := Flush(ctx); err != nil {
+		t.Fatalf("message should be sent: %v", err)
+	}
+	if messages := fake.Messages(); len(messages) != 1 || messages[0].Text != "done" {
+		t.Fatalf("not expected messages %v", messages)
+	}
+}
+
+func TestRestoreInfo(t *testing.T) {
+	ref := MessageRef{ChatID: -100, MessageID: 5, ThreadID: 9, UserID: 7, Language: "ru"}
+	info := RestoreInfo(ref)
+	if info.Ref() != ref {
+		t.Fatalf("expected %v, got %v", ref, info.Ref())
+	}
+}
@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// telegram limit of message text
+const maxMessageLength = 4096
+
+type openTag struct {
+	name    string
+	opening string
+}
+
+type splitter struct {
+	limit      int
+	html       bool
+	parts      []string
+	current    strings.Builder
+	hasContent bool
+	stack      []openTag
+}
+
+var tagNameRegexp = regexp.MustCompile(`^</?([a-zA-Z][a-zA-Z0-9-]*)`)
+var tagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// split text into parts not longer than limit, text is split on line boundaries
+// and only too long lines are split in the middle, never inside a rune, tag or entity.
+// For html, tags open at the end of part are closed and opened again in the next part
+func SplitMessage(text string, limit int, html bool) []string {
+	if textLength(text) <= limit {
+		return []string{text}
+	}
+
+	s := &splitter{limit: limit, html: html}
+	lines := strings.SplitAfter(text, "\n")
+	for _, line := range lines {
+		s.addLine(line)
+	}
+	s.flush()
+	return s.parts
+}
+
+// length in UTF-16 code units, the way telegram counts it
+func textLength(text string) int {
+	length := 0
+	for _, r := range text {
+		if r > 0xFFFF {
+			length += 2
+		} else {
+			length++
+		}
+	}
+	return length
+}
+
+func (s *splitter) addLine(line string) {
+	tokens := s.tokenize(line)
+	stackAfter := s.apply(s.stack, tokens...)
+	if s.fits(line, stackAfter) {
+		s.write(line, stackAfter)
+		return
+	}
+
+	if s.hasContent {
+		s.flush()
+		if s.fits(line, stackAfter) {
+			s.write(line, stackAfter)
+			return
+		}
+	}
+
+	// line is longer than limit, add it token by token
+	for _, token := range tokens {
+		stackAfter := s.apply(s.stack, token)
+		if !s.fits(token, stackAfter) && s.hasContent {
+			s.flush()
+		}
+		s.write(token, stackAfter)
+	}
+}
+
+func (s *splitter) fits(text string, stackAfter []openTag) bool {
+	return textLength(s.current.String())+textLength(text)+textLength(closingTags(stackAfter)) <= s.limit
+}
+
+func (s *splitter) write(text string, stackAfter []openTag) {
+	s.current.WriteString(text)
+	s.stack = stackAfter
+	if s.html {
+		text = tagRegexp.ReplaceAllString(text, "")
+	}
+	if strings.TrimSpace(text) != "" {
+		s.hasContent = true
+	}
+}
+
+// finish current part and start the next one with reopened tags
+func (s *splitter) flush() {
+	if s.hasContent {
+		part := strings.TrimRight(s.current.String(), "\n")
+		s.parts = append(s.parts, part+closingTags(s.stack))
+	}
+
+	s.current.Reset()
+	for _, tag := range s.stack {
+		s.current.WriteString(tag.opening)
+	}
+	s.hasContent = false
+}
+
+// split line into runes, and for html also into whole tags and entities
+func (s *splitter) tokenize(line string) []string {
+	var tokens []string
+	for len(line) > 0 {
+		size := 0
+		if s.html && line[0] == '<' {
+			size = strings.IndexByte(line, '>') + 1
+		} else if s.html && line[0] == '&' {
+			end := strings.IndexByte(line, ';')
+			if end > 0 && end < 10 {
+				size = end + 1
+			}
+		}
+		if size <= 0 {
+			_, size = utf8.DecodeRuneInString(line)
+		}
+		tokens = append(tokens, line[:size])
+		line = line[size:]
+	}
+	return tokens
+}
+
+// returns stack of open tags after given tokens
+func (s *splitter) apply(stack []openTag, tokens ...string) []openTag {
+	if !s.html {
+		return stack
+	}
+
+	result := append([]openTag{}, stack...)
+	for _, token := range tokens {
+		match := tagNameRegexp.FindStringSubmatch(token)
+		if match == nil || !strings.HasSuffix(token, ">") {
+			continue
+		}
+
+		name := strings.ToLower(match[1])
+		if strings.HasPrefix(token, "</") {
+			for i := len(result) - 1; i >= 0; i-- {
+				if result[i].name == name {
+					result = result[:i]
+					break
+				}
+			}
+		} else if !strings.HasSuffix(token, "/>") {
+			result = append(result, openTag{name: name, opening: token})
+		}
+	}
+	return result
+}
+
+func closingTags(stack []openTag) string {
+	var result strings.Builder
+	for i := len(stack) - 1; i >= 0; i-- {
+		result.WriteString("</" + stack[i].name + ">")
+	}
+	return result.String()
+}
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitShortMessage(t *testing.T) {
+	parts := SplitMessage("short", 10, false)
+	if len(parts) != 1 || parts[0] != "short" {
+		t.Fatalf("not expected %v", parts)
+	}
+}
+
+func TestSplitOnLineBoundaries(t *testing.T) {
+	text := "line one\nline two\nline three\n"
+	parts := SplitMessage(text, 20, false)
+	expected := []string{"line one\nline two", "line three"}
+	if strings.Join(parts, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %q, actual %q", expected, parts)
+	}
+}
+
+func TestSplitKeepsAllText(t *testing.T) {
+	var lines []string
+	for i := 0; i < 500; i++ {
+		lines = append(lines, "Фильм номер "+strings.Repeat("я", i%30))
+	}
+	text := strings.Join(lines, "\n")
+	parts := SplitMessage(text, maxMessageLength, false)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	for _, part := range parts {
+		if textLength(part) > maxMessageLength {
+			t.Fatalf("part is too long %d", textLength(part))
+		}
+	}
+	if strings.Join(parts, "\n") != text {
+		t.Fatalf("text was changed by split")
+	}
+}
+
+func TestSplitLongLineOnRuneBoundary(t *testing.T) {
+	text := strings.Repeat("ж", 25)
+	parts := SplitMessage(text, 10, false)
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %q", parts)
+	}
+	for _, part := range parts {
+		if !utf8.ValidString(part) {
+			t.Fatalf("rune was split %q", part)
+		}
+	}
+	if strings.Join(parts, "") != text {
+		t.Fatalf("text was changed by split")
+	}
+}
+
+func TestSplitClosesAndReopensTags(t *testing.T) {
+	text := "<b>first line\nsecond line\nthird line</b>\n<a href=\"https://t.me\">link</a>"
+	parts := SplitMessage(text, 31, true)
+	expected := []string{"<b>first line\nsecond line</b>", "<b>third line</b>", "<a href=\"https://t.me\">link</a>"}
+	if strings.Join(parts, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %q, actual %q", expected, parts)
+	}
+}
+
+func TestSplitDoesNotBreakTagsAndEntities(t *testing.T) {
+	text := strings.Repeat("<i>a&amp;b</i>", 10)
+	parts := SplitMessage(text, 20, true)
+	for _, part := range parts {
+		if strings.Count(part, "<i>") != strings.Count(part, "</i>") {
+			t.Fatalf("tags are not balanced in %q", part)
+		}
+		if strings.Count(part, "&") != strings.Count(part, "&amp;") {
+			t.Fatalf("entity was split in %q", part)
+		}
+		if textLength(part) > 20 {
+			t.Fatalf("part is too long %q", part)
+		}
+	}
+}
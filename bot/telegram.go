@@ -1,26 +1,105 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/telegram-command-reader/i18n"
 )
 
 type Info struct {
 	Text     string
 	FileName string
 	FileUrl  string
+	FileID   string // stays valid, unlike FileUrl which expires in an hour
 	source   *tgbotapi.Message
 	callback *tgbotapi.CallbackQuery
+	inline   *tgbotapi.InlineQuery
+}
+
+// chat of the message, 0 if unknown
+func (info *Info) ChatID() int64 {
+	if info.source != nil && info.source.Chat != nil {
+		return info.source.Chat.ID
+	}
+	return 0
+}
+
+// id of the message, for button press it is the message with the keyboard
+func (info *Info) MessageID() int {
+	if info.source != nil {
+		return info.source.MessageID
+	}
+	return 0
+}
+
+// forum topic of the message, 0 if the chat has no topics
+func (info *Info) ThreadID() int {
+	return threads.get(info.ChatID(), info.MessageID())
+}
+
+// user who sent the message, pressed the button or typed inline query
+func (info *Info) from() *tgbotapi.User {
+	if info.callback != nil && info.callback.From != nil {
+		return info.callback.From
+	}
+	if info.inline != nil && info.inline.From != nil {
+		return info.inline.From
+	}
+	if info.source != nil && info.source.From != nil {
+		return info.source.From
+	}
+	return nil
+}
+
+// user who sent the message or pressed the button, 0 if unknown
+func (info *Info) UserID() int64 {
+	if user := info.from(); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+// language chosen by the user with /lang, or language of user's telegram app
+func (info *Info) Language() string {
+	user := info.from()
+	if user == nil {
+		return i18n.DefaultLanguage
+	}
+	if lang, ok := i18n.UserLanguage(user.ID); ok {
+		return lang
+	}
+	return i18n.Match(user.LanguageCode)
+}
+
+// translate key to the language of the user
+func (info *Info) T(key i18n.Key, args ...interface{}) string {
+	return i18n.T(info.Language(), key, args...)
 }
 
 var API_TOKEN string
+
+// url of self-hosted bot api server like http://localhost:8081, api.telegram.org if empty
+var API_URL string
+
 var botInstance *tgbotapi.BotAPI
 
+// telegram accepts documents up to 50 MB, self-hosted bot api server up to 2000 MB
+func MaxUploadSize() int64 {
+	if API_URL != "" {
+		return 2000 << 20
+	}
+	return 50 << 20
+}
+
 // create bot, print error if any, do not panic
 // retry if error
 func createBot() *tgbotapi.BotAPI {
@@ -28,13 +107,19 @@ func createBot() *tgbotapi.BotAPI {
 		return botInstance
 	}
 
-	bot, err := tgbotapi.NewBotAPI(API_TOKEN)
+	endpoint := tgbotapi.APIEndpoint
+	if API_URL != "" {
+		endpoint = strings.TrimRight(API_URL, "/") + "/bot%s/%s"
+	}
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(API_TOKEN, endpoint)
 	if err != nil {
 		fmt.Println("error create bot ", err)
 		fmt.Println("retry in 15 seconds")
 		time.Sleep(time.Second * 15)
 		return createBot()
 	}
+	bot.Debug = false
+	botInstance = bot
 	return bot
 }
 
@@ -73,18 +158,11 @@ func NewInlineResponseMatcher() *InlineResponseMatcher {
 	return &InlineResponseMatcher{}
 }
 
-var pair = make(map[Matcher]Hanlder)
-
-// add handler to list
-func AddHandler(matcher Matcher, handler Hanlder) {
-	pair[matcher] = handler
-}
-
-func findHandlerForUpdate(update *tgbotapi.Update) (Hanlder, bool) {
-	// iterate through pairs of matcher-handler
-	for matcher, handler := range pair {
-		if matcher.match(update) {
-			return handler, true
+func findHandlerForUpdate(update *tgbotapi.Update) (*Route, bool) {
+	// iterate through routes, the first matching one wins
+	for _, route := range orderedRoutes() {
+		if route.matcher.match(update) {
+			return route, true
 		}
 	}
 
@@ -117,11 +195,22 @@ func (matcher *FileNameMatcher) match(update *tgbotapi.Update) bool {
 	return update.Message.Document != nil
 }
 
-func SendTypingStatus(info *Info) {
-	bot := createBot()
+// download url of the file sent to the bot earlier
+func FileURL(fileID string) (string, error) {
+	return getTransport().GetFileURL(fileID)
+}
 
+func SendTypingStatus(info *Info) {
+	if threadID := info.ThreadID(); threadID != 0 {
+		params := tgbotapi.Params{}
+		params.AddNonZero64("chat_id", info.source.Chat.ID)
+		params.AddNonZero("message_thread_id", threadID)
+		params.AddNonEmpty("action", tgbotapi.ChatTyping)
+		getTransport().MakeRequest("sendChatAction", params)
+		return
+	}
 	msg := tgbotapi.NewChatAction(info.source.Chat.ID, tgbotapi.ChatTyping)
-	bot.Send(msg)
+	getTransport().Request(msg)
 }
 
 // public enum of values for inline response
@@ -135,59 +224,298 @@ const (
 	TextBooks             = "TextBooks"
 	MessageMore           = "MessageMore"
 	MessageProviderSearch = "MessageProviderSearch"
+	MessageSizeLimit      = "MessageSizeLimit"
+	ConfirmYes            = "ConfirmYes"
+	ConfirmNo             = "ConfirmNo"
+	SendFiles             = "SendFiles"
+	ListPrevious          = "ListPrevious"
+	ListNext              = "ListNext"
+	DeleteTorrent         = "DeleteTorrent"
+	DeleteWithFiles       = "DeleteWithFiles"
+	InfoSummary           = "InfoSummary"
+	InfoFiles             = "InfoFiles"
+	InfoPeers             = "InfoPeers"
+	InfoTrackers          = "InfoTrackers"
+	FilesAll              = "FilesAll"
+	FilesNone             = "FilesNone"
+	TurtleOn              = "TurtleOn"
+	TurtleOff             = "TurtleOff"
+	SpeedUnlimited        = "SpeedUnlimited"
 )
 
-var CategoriesKeyboard = tgbotapi.NewInlineKeyboardMarkup(
-	tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("Везде", All),
-		tgbotapi.NewInlineKeyboardButtonData("Фильмы", Movies),
-	),
-	tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("Сериалы", Series),
-		tgbotapi.NewInlineKeyboardButtonData("Аудиокниги", Audiobooks),
-		tgbotapi.NewInlineKeyboardButtonData("Книги", TextBooks),
-	),
+// sort buttons of torrent lists, values are the sort orders of transmission
+const (
+	SortByName     = "name"
+	SortByProgress = "progress"
+	SortBySize     = "size"
+	SortByAdded    = "added"
 )
 
-var DownloadActionKeyboard = tgbotapi.NewInlineKeyboardMarkup(
-	tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("Скачать торрент файл", DownloadActionFile),
-		tgbotapi.NewInlineKeyboardButtonData("На сервер", DownloadActionServer),
-	),
+// modes of file selection, pressed file is toggled or gets the priority
+const (
+	FilesModeWanted = "wanted"
+	FilesModeHigh   = "high"
+	FilesModeNormal = "normal"
+	FilesModeLow    = "low"
 )
 
-var MessageActionKeyboard = tgbotapi.NewInlineKeyboardMarkup(
-	tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("Следующие результаты", MessageMore),
-		tgbotapi.NewInlineKeyboardButtonData("Искать в других местах", MessageProviderSearch),
-	),
+// prefix of pressed file button, it is followed by index of the file
+const FilePrefix = "file_"
+
+// buttons to control torrents, values are the actions of transmission
+const (
+	ControlStop       = "stop"
+	ControlStart      = "start"
+	ControlStartNow   = "startnow"
+	ControlVerify     = "verify"
+	ControlReannounce = "reannounce"
 )
 
-func RequestUpdates() {
-	bot := createBot()
-	bot.Debug = false
+func CategoriesKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonEverywhere), action, All),
+			NewButton(i18n.T(lang, i18n.ButtonMovies), action, Movies),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonSeries), action, Series),
+			NewButton(i18n.T(lang, i18n.ButtonAudiobooks), action, Audiobooks),
+			NewButton(i18n.T(lang, i18n.ButtonBooks), action, TextBooks),
+		),
+	)
+}
+
+func DownloadActionKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonTorrentFile), action, DownloadActionFile),
+			NewButton(i18n.T(lang, i18n.ButtonToServer), action, DownloadActionServer),
+		),
+	)
+}
+
+// keyboard of search result posted via inline mode
+func InlineDownloadKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonDownloadOnServer), action, DownloadActionServer),
+		),
+	)
+}
+
+// keyboard of finished download
+func SendFilesKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonSendFiles), action, SendFiles),
+		),
+	)
+}
+
+func MessageActionKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonMore), action, MessageMore),
+			NewButton(i18n.T(lang, i18n.ButtonOtherProviders), action, MessageProviderSearch),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonSizeLimit), action, MessageSizeLimit),
+		),
+	)
+}
+
+// keyboard for search results which fit into one message
+func MessageFilterKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonSizeLimit), action, MessageSizeLimit),
+			NewButton(i18n.T(lang, i18n.ButtonOtherProviders), action, MessageProviderSearch),
+		),
+	)
+}
+
+// keyboard to confirm download
+func ConfirmKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonDownload), action, ConfirmYes),
+			NewButton(i18n.T(lang, i18n.ButtonCancel), action, ConfirmNo),
+		),
+	)
+}
+
+// keyboard of torrent list page, page is from 0, current sort is marked
+func TorrentListKeyboard(lang string, action *Action, page int, pages int, sortBy string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var paging []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		paging = append(paging, NewButton(i18n.T(lang, i18n.ButtonPrevious), action, ListPrevious))
+	}
+	if page < pages-1 {
+		paging = append(paging, NewButton(i18n.T(lang, i18n.ButtonNext), action, ListNext))
+	}
+	if len(paging) > 0 {
+		rows = append(rows, paging)
+	}
+
+	var sorting []tgbotapi.InlineKeyboardButton
+	for _, sort := range []struct {
+		value string
+		label i18n.Key
+	}{{SortByName, i18n.ButtonSortName}, {SortByProgress, i18n.ButtonSortProgress}, {SortBySize, i18n.ButtonSortSize}, {SortByAdded, i18n.ButtonSortAdded}} {
+		text := i18n.T(lang, sort.label)
+		if sort.value == sortBy {
+			text = "• " + text
+		}
+		sorting = append(sorting, NewButton(text, action, sort.value))
+	}
+	rows = append(rows, sorting[:2], sorting[2:])
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// keyboard to confirm removal of torrent, cancel button has value ConfirmNo
+func DeleteKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonDeleteTorrent), action, DeleteTorrent),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonDeleteWithFiles), action, DeleteWithFiles),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonCancel), action, ConfirmNo),
+		),
+	)
+}
+
+// keyboard with sections of torrent details, current section is marked
+func TorrentInfoKeyboard(lang string, action *Action, current string) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, section := range []struct {
+		value string
+		label i18n.Key
+	}{{InfoSummary, i18n.ButtonInfoSummary}, {InfoFiles, i18n.ButtonInfoFiles}, {InfoPeers, i18n.ButtonInfoPeers}, {InfoTrackers, i18n.ButtonInfoTrackers}} {
+		text := i18n.T(lang, section.label)
+		if section.value == current {
+			text = "• " + text
+		}
+		row = append(row, NewButton(text, action, section.value))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// FileChoice is a button of file in file selection keyboard
+type FileChoice struct {
+	Index int
+	Text  string
+}
 
-	// Create a new UpdateConfig struct with an offset of 0. Offsets are used
-	// to make sure Telegram knows we've handled previous values and we don't
-	// need them repeated.
-	updateConfig := tgbotapi.NewUpdate(0)
+// keyboard to choose files of torrent on one page, page is from 0, current mode is marked
+func FilesKeyboard(lang string, action *Action, files []FileChoice, page int, pages int, mode string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, file := range files {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(NewButton(file.Text, action, FilePrefix+strconv.Itoa(file.Index))))
+	}
+
+	var paging []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		paging = append(paging, NewButton(i18n.T(lang, i18n.ButtonPrevious), action, ListPrevious))
+	}
+	if page < pages-1 {
+		paging = append(paging, NewButton(i18n.T(lang, i18n.ButtonNext), action, ListNext))
+	}
+	if len(paging) > 0 {
+		rows = append(rows, paging)
+	}
+
+	var modes []tgbotapi.InlineKeyboardButton
+	for _, m := range []struct {
+		value string
+		label i18n.Key
+	}{{FilesModeWanted, i18n.ButtonFilesWanted}, {FilesModeHigh, i18n.ButtonPriorityHigh}, {FilesModeNormal, i18n.ButtonPriorityNormal}, {FilesModeLow, i18n.ButtonPriorityLow}} {
+		text := i18n.T(lang, m.label)
+		if m.value == mode {
+			text = "• " + text
+		}
+		modes = append(modes, NewButton(text, action, m.value))
+	}
+	rows = append(rows, modes, tgbotapi.NewInlineKeyboardRow(
+		NewButton(i18n.T(lang, i18n.ButtonFilesAll), action, FilesAll),
+		NewButton(i18n.T(lang, i18n.ButtonFilesNone), action, FilesNone),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
 
-	// Tell Telegram we should wait up to 30 seconds on each request for an
-	// update. This way we can get information just as quickly as making many
-	// frequent requests without having to send nearly as many.
-	updateConfig.Timeout = 30
+// keyboard to control torrent or all torrents
+func TorrentControlKeyboard(lang string, action *Action) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonStop), action, ControlStop),
+			NewButton(i18n.T(lang, i18n.ButtonStart), action, ControlStart),
+			NewButton(i18n.T(lang, i18n.ButtonStartNow), action, ControlStartNow),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonVerify), action, ControlVerify),
+			NewButton(i18n.T(lang, i18n.ButtonReannounce), action, ControlReannounce),
+		),
+	)
+}
 
-	// Start polling Telegram for updates.
-	updates := bot.GetUpdatesChan(updateConfig)
+// keyboard to switch turtle mode and to remove speed limits
+func SpeedKeyboard(lang string, action *Action, turtle bool) tgbotapi.InlineKeyboardMarkup {
+	toggle := NewButton(i18n.T(lang, i18n.ButtonTurtleOn), action, TurtleOn)
+	if turtle {
+		toggle = NewButton(i18n.T(lang, i18n.ButtonTurtleOff), action, TurtleOff)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(toggle),
+		tgbotapi.NewInlineKeyboardRow(
+			NewButton(i18n.T(lang, i18n.ButtonUnlimited), action, SpeedUnlimited),
+		),
+	)
+}
 
+// keyboard with supported languages, value of the button is the language
+func LanguageKeyboard(action *Action) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, lang := range i18n.Languages() {
+		row = append(row, NewButton(i18n.T(lang, i18n.LanguageChanged), action, lang))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// receive updates by polling and pass them to handlers until ctx is done
+func RequestUpdates(ctx context.Context) {
+	handleUpdates(ctx, getTransport().Updates(ctx))
+}
+
+// pass every update to matching handler or reply callback, until ctx is done or updates are closed
+func handleUpdates(ctx context.Context, updates tgbotapi.UpdatesChannel) {
 	// Let's go through each update that we're getting from Telegram.
-	for update := range updates {
+	for {
+		var update tgbotapi.Update
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped getting updates")
+			return
+		case received, ok := <-updates:
+			if !ok {
+				fmt.Println("Warning: Finished getting updates")
+				return
+			}
+			update = received
+		}
+
 		// Telegram can send many types of updates depending on what your Bot
 		// is up to. We only want to look at messages for now, so we can
 		// discard any other updates.
 		if update.Message != nil {
+			if !addressedToBot(update.Message, getTransport().Self()) {
+				continue
+			}
+
 			// check if matchers match
-			handler, ok := findHandlerForUpdate(&update)
+			route, ok := findHandlerForUpdate(&update)
 			if !ok {
 				fmt.Println("no handler for update")
 				continue
@@ -195,10 +523,12 @@ func RequestUpdates() {
 
 			var fileUrl string
 			var fileName string
+			var fileID string
 
 			if update.Message.Document != nil {
 				var err error = nil
-				fileUrl, err = bot.GetFileDirectURL(update.Message.Document.FileID)
+				fileID = update.Message.Document.FileID
+				fileUrl, err = getTransport().GetFileURL(fileID)
 				fileName = update.Message.Document.FileName
 				if err != nil {
 					fmt.Println("error get url ", err)
@@ -206,27 +536,17 @@ func RequestUpdates() {
 				}
 			}
 
-			handler(&Info{
+			startHandler(route, route.handler, &Info{
 				Text:     update.Message.Text,
 				FileName: fileName,
 				FileUrl:  fileUrl,
+				FileID:   fileID,
 				source:   update.Message,
 			})
+		} else if update.CallbackQuery != nil && update.CallbackQuery.InlineMessageID != "" {
+			handleInlineCallback(update.CallbackQuery)
 		} else if update.CallbackQuery != nil {
-			// find if we have reply function for the message in hash and call it
-			replyCallback, ok := replyCallbacks[update.CallbackQuery.Message.MessageID]
-			if ok {
-				replyCallback(update.CallbackQuery.Data)
-			}
-
-			// Respond to the callback query, telling Telegram to show the user
-			// a message with the data received.
-			callback := tgbotapi.NewCallback(update.CallbackQuery.ID, update.CallbackQuery.Data)
-			if _, err := bot.Request(callback); err != nil {
-				msg := tgbotapi.NewMessage(update.CallbackQuery.Message.Chat.ID, err.Error())
-				bot.Send(msg)
-				continue
-			}
+			handleCallback(update.CallbackQuery)
 
 			// originalText := update.CallbackQuery.Message.ReplyToMessage.Text
 			// // And finally, send a message containing the data received.
@@ -235,13 +555,13 @@ func RequestUpdates() {
 			// 	fmt.Println(err.Error())
 			// 	continue
 			// }
+		} else if update.InlineQuery != nil {
+			handleInlineQuery(update.InlineQuery)
 		} else {
 			fmt.Println("update without message")
 			continue
 		}
 	}
-
-	fmt.Println("Warning: Finished getting updates")
 }
 
 type OutMessage struct {
@@ -250,72 +570,206 @@ type OutMessage struct {
 	Html              bool
 	UseInlineKeyboard bool
 	InlineKeyboard    tgbotapi.InlineKeyboardMarkup
-	ReplyCallback     func(string)
+	Action            *Action // called when plain button of InlineKeyboard is pressed, not needed for buttons created by NewButton
 	FileStream        io.ReadCloser
+	EditMessageID     int       // edit this message in chat of OriginalMessage instead of sending new one, only keyboard is edited if Text is empty
+	OnSent            func(int) // called with id of sent or edited message
 }
 
-// map of reply callbacks
-var replyCallbacks = make(map[int]func(string))
+// run action of pressed button, it is encoded in the button or saved for the message
+// with plain buttons, or tell user that the menu expired
+func handleCallback(query *tgbotapi.CallbackQuery) {
+	action, value, ok := decodePayload(query.Data)
+	if !ok && query.Message != nil {
+		action, ok = callbacks.get(query.Message.Chat.ID, query.Message.MessageID)
+		value = query.Data
+	}
+	var handler ActionHandler
+	if ok {
+		handler, ok = actionHandlers[action.Name]
+	}
+
+	// Respond to the callback query, so telegram stops showing progress on the button
+	info := &Info{Text: value, source: query.Message, callback: query}
+	answer := tgbotapi.NewCallback(query.ID, "")
+	if !ok {
+		fmt.Println("expired callback ", query.Data)
+		answer = tgbotapi.NewCallbackWithAlert(query.ID, info.T(i18n.MenuExpired))
+	} else if text, denied := deniedAction(info, action.Name); denied {
+		answer = tgbotapi.NewCallbackWithAlert(query.ID, text)
+		ok = false
+	}
+	if err := getTransport().AnswerCallback(answer); err != nil {
+		fmt.Println("error answer callback ", err)
+	}
+
+	if ok {
+		startHandler(callbackRoute, func(info *Info) {
+			handler(info, action.Params)
+		}, info)
+	}
+}
 
+// read messages from the channel and put them to queues of their chats
 func Sender(sendChannel chan OutMessage) {
-	bot := createBot()
+	outgoing.mutex.Lock()
+	outgoing.deliver = deliver
+	outgoing.input = sendChannel
+	outgoing.mutex.Unlock()
 
 	for receivedMessage := range sendChannel {
-		telegramMessage := receivedMessage.OriginalMessage.source
-
-		// if toSend.text is more than 4096 symbols, trim it
-		if len(receivedMessage.Text) > 4096 {
-			receivedMessage.Text = receivedMessage.Text[:4096]
-		}
+		outgoing.enqueue(receivedMessage)
+	}
+}
 
-		// Now that we know we've gotten a new message, we can construct a
-		// reply! We'll take the Chat ID and Text from the incoming message
-		// and use it to create a new message.
-		msg := tgbotapi.NewMessage(telegramMessage.Chat.ID, receivedMessage.Text)
-		if receivedMessage.Html {
-			msg.ParseMode = "HTML"
+// send message, long text is split, edit request is made if EditMessageID is set
+func deliver(receivedMessage OutMessage) {
+	telegramMessage := receivedMessage.OriginalMessage.source
+
+	if receivedMessage.EditMessageID != 0 {
+		// edited message can't be split, keep only the first part
+		receivedMessage.Text = SplitMessage(receivedMessage.Text, maxMessageLength, receivedMessage.Html)[0]
+		sendMessage(editConfig(telegramMessage.Chat.ID, receivedMessage), receivedMessage)
+	} else if receivedMessage.FileStream != nil {
+		file := tgbotapi.FileReader{
+			Name:   receivedMessage.Text,
+			Reader: receivedMessage.FileStream,
 		}
 
-		// We'll also say that this message is a reply to the previous message.
-		// For any other specifications than Chat ID or Text, you'll need to
-		// set fields on the `MessageConfig`.
-		msg.ReplyToMessageID = telegramMessage.MessageID
-		if receivedMessage.UseInlineKeyboard {
-			msg.ReplyMarkup = receivedMessage.InlineKeyboard
-		}
+		sendMessage(tgbotapi.NewDocument(telegramMessage.Chat.ID, file), receivedMessage)
+		receivedMessage.FileStream.Close()
+	} else {
+		// long text is sent as several messages, keyboard is attached to the last one
+		parts := SplitMessage(receivedMessage.Text, maxMessageLength, receivedMessage.Html)
+		for i, part := range parts {
+			last := i == len(parts)-1
+
+			// Now that we know we've gotten a new message, we can construct a
+			// reply! We'll take the Chat ID and Text from the incoming message
+			// and use it to create a new message.
+			msg := tgbotapi.NewMessage(telegramMessage.Chat.ID, part)
+			if receivedMessage.Html {
+				msg.ParseMode = "HTML"
+			}
 
-		if receivedMessage.FileStream != nil {
-			file := tgbotapi.FileReader{
-				Name:   receivedMessage.Text,
-				Reader: receivedMessage.FileStream,
+			// We'll also say that this message is a reply to the previous message.
+			// For any other specifications than Chat ID or Text, you'll need to
+			// set fields on the `MessageConfig`.
+			msg.ReplyToMessageID = telegramMessage.MessageID
+			toSend := receivedMessage
+			if last && receivedMessage.UseInlineKeyboard {
+				msg.ReplyMarkup = receivedMessage.InlineKeyboard
+			}
+			if !last {
+				toSend.Action = nil
+				toSend.OnSent = nil
 			}
 
-			sendMessage(bot, tgbotapi.NewDocument(telegramMessage.Chat.ID, file), receivedMessage)
-			receivedMessage.FileStream.Close()
-		} else {
-			sendMessage(bot, msg, receivedMessage)
+			sendMessage(msg, toSend)
 		}
 	}
 }
 
-func sendMessage(bot *tgbotapi.BotAPI, msg tgbotapi.Chattable, toSend OutMessage) {
+// editMessageText or editMessageReplyMarkup request for the message
+func editConfig(chatID int64, toSend OutMessage) tgbotapi.Chattable {
+	if toSend.Text == "" {
+		keyboard := toSend.InlineKeyboard
+		if keyboard.InlineKeyboard == nil {
+			// empty list removes the keyboard, null is rejected by telegram
+			keyboard.InlineKeyboard = [][]tgbotapi.InlineKeyboardButton{}
+		}
+		return tgbotapi.NewEditMessageReplyMarkup(chatID, toSend.EditMessageID, keyboard)
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, toSend.EditMessageID, toSend.Text)
+	if toSend.Html {
+		edit.ParseMode = "HTML"
+	}
+	if toSend.UseInlineKeyboard {
+		edit.ReplyMarkup = &toSend.InlineKeyboard
+	}
+	return edit
+}
+
+// telegram returns error when edited message is the same as before
+func isNotModified(err error) bool {
+	var apiError *tgbotapi.Error
+	return errors.As(err, &apiError) && strings.Contains(apiError.Message, "message is not modified")
+}
+
+// how many times to wait when telegram asks to retry later because of flood control
+const maxFloodWaits = 10
+
+func sendMessage(msg tgbotapi.Chattable, toSend OutMessage) {
+	floodWaits := 0
 	// we need to wait for user reply, add message to hashmap by id
 	for i := 0; i < 3; i++ {
-		sentMessage, err := bot.Send(msg)
+		if i > 0 || floodWaits > 0 {
+			// file was read by the failed request, it is sent again from the start
+			if err := rewindFile(msg); err != nil {
+				fmt.Println("error send file again ", err)
+				return
+			}
+		}
+		outgoing.waitForSlot(toSend.OriginalMessage.ChatID())
+		var sentMessage tgbotapi.Message
+		var err error
+		if toSend.EditMessageID != 0 {
+			sentMessage, err = getTransport().Edit(msg)
+		} else if threadID := toSend.OriginalMessage.ThreadID(); threadID != 0 {
+			sentMessage, err = getTransport().SendToThread(msg, threadID)
+		} else {
+			sentMessage, err = getTransport().Send(msg)
+		}
+		if isNotModified(err) {
+			return
+		}
+
+		var apiError *tgbotapi.Error
+		if errors.As(err, &apiError) && apiError.RetryAfter > 0 && floodWaits < maxFloodWaits {
+			fmt.Printf("flood control in chat %d, retry after %d seconds\n", toSend.OriginalMessage.ChatID(), apiError.RetryAfter)
+			atomic.AddInt64(&outgoing.throttled, 1)
+			time.Sleep(time.Duration(apiError.RetryAfter) * time.Second)
+			floodWaits++
+			i--
+			continue
+		}
+
 		if err != nil {
 			fmt.Println("error send message ", err)
 			time.Sleep(time.Second * 3)
 			continue
 		}
 
-		if toSend.ReplyCallback != nil {
-			replyCallbacks[sentMessage.MessageID] = toSend.ReplyCallback
+		if toSend.Action != nil {
+			callbacks.put(sentMessage.Chat.ID, sentMessage.MessageID, *toSend.Action)
+		}
+		if toSend.OnSent != nil {
+			toSend.OnSent(sentMessage.MessageID)
 		}
 
 		break
 	}
 }
 
+// seek file of the document to the start, error if the file can't be read again
+func rewindFile(msg tgbotapi.Chattable) error {
+	document, ok := msg.(tgbotapi.DocumentConfig)
+	if !ok {
+		return nil
+	}
+	file, ok := document.File.(tgbotapi.FileReader)
+	if !ok {
+		return nil
+	}
+	seeker, ok := file.Reader.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("%s can't be rewound", file.Name)
+	}
+	_, err := seeker.Seek(0, io.SeekStart)
+	return err
+}
+
 // encode string
 func EncodeString(value string) string {
 	// Replace spaces in encoded value with %&
@@ -2,7 +2,10 @@ package bot
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -15,11 +18,10 @@ func TestMain(m *testing.M) {
 }
 
 func setup() {
-	// clear pairs
-	fmt.Println("setup", len(pair))
-	for m := range pair {
-		delete(pair, m)
-	}
+	// clear routes
+	fmt.Println("setup", len(routes))
+	routes = nil
+	middlewares = nil
 }
 
 // test command matcher match works
@@ -134,3 +136,103 @@ func TestFinalMatches(t *testing.T) {
 		}
 	}
 }
+
+func TestEditConfigText(t *testing.T) {
+	edit, ok := editConfig(5, OutMessage{Text: "50%", Html: true, EditMessageID: 7}).(tgbotapi.EditMessageTextConfig)
+	if !ok {
+		t.Fatalf("expected edit of message text")
+	}
+	if edit.ChatID != 5 || edit.MessageID != 7 || edit.Text != "50%" || edit.ParseMode != "HTML" || edit.ReplyMarkup != nil {
+		t.Fatalf("not expected edit %v", edit)
+	}
+}
+
+func TestEditConfigKeyboardOnly(t *testing.T) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("a", "b")))
+	edit, ok := editConfig(5, OutMessage{EditMessageID: 7, UseInlineKeyboard: true, InlineKeyboard: keyboard}).(tgbotapi.EditMessageReplyMarkupConfig)
+	if !ok {
+		t.Fatalf("expected edit of reply markup")
+	}
+	if edit.MessageID != 7 || len(edit.ReplyMarkup.InlineKeyboard) != 1 {
+		t.Fatalf("not expected edit %v", edit)
+	}
+}
+
+func TestIsNotModified(t *testing.T) {
+	if !isNotModified(&tgbotapi.Error{Code: 400, Message: "Bad Request: message is not modified"}) {
+		t.Fatalf("expected not modified error")
+	}
+	if isNotModified(&tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}) || isNotModified(nil) {
+		t.Fatalf("not expected not modified error")
+	}
+}
+
+func TestEditConfigRemovesKeyboard(t *testing.T) {
+	edit := editConfig(5, OutMessage{EditMessageID: 7}).(tgbotapi.EditMessageReplyMarkupConfig)
+	if edit.ReplyMarkup == nil || edit.ReplyMarkup.InlineKeyboard == nil || len(edit.ReplyMarkup.InlineKeyboard) != 0 {
+		t.Fatalf("expected empty keyboard %v", edit.ReplyMarkup)
+	}
+}
+
+func TestFilesKeyboard(t *testing.T) {
+	RegisterAction("files_test", RoleMember, func(info *Info, params map[string]string) {}, "id", "page", "mode")
+	action := NewAction("files_test", map[string]string{"id": "12", "page": "0", "mode": FilesModeWanted})
+	keyboard := FilesKeyboard("en", action, []FileChoice{{Index: 0, Text: "a"}, {Index: 3, Text: "b"}}, 0, 2, FilesModeWanted)
+
+	rows := keyboard.InlineKeyboard
+	// two files, next page, modes, all and none
+	if len(rows) != 5 || len(rows[1]) != 1 || len(rows[2]) != 1 || len(rows[3]) != 4 {
+		t.Fatalf("not expected keyboard %v", rows)
+	}
+	decoded, value, ok := decodePayload(*rows[1][0].CallbackData)
+	if !ok || value != FilePrefix+"3" || decoded.Params["id"] != "12" || decoded.Params["mode"] != FilesModeWanted {
+		t.Fatalf("not expected payload %v %s", decoded, value)
+	}
+	if !strings.HasPrefix(rows[3][0].Text, "• ") {
+		t.Fatalf("current mode is not marked: %s", rows[3][0].Text)
+	}
+}
+
+// fails the first send with flood control, documents are read completely like by http client
+type flakyTransport struct {
+	*FakeTransport
+	mutex    sync.Mutex
+	failed   bool
+	contents []string
+}
+
+func (f *flakyTransport) Send(message tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mutex.Lock()
+	if document, ok := message.(tgbotapi.DocumentConfig); ok {
+		data, _ := io.ReadAll(document.File.(tgbotapi.FileReader).Reader)
+		f.contents = append(f.contents, string(data))
+	}
+	failed := f.failed
+	f.failed = true
+	f.mutex.Unlock()
+	if !failed {
+		return tgbotapi.Message{}, &tgbotapi.Error{Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1}}
+	}
+	return f.FakeTransport.Send(message)
+}
+
+func TestFileIsSentAgainFromStart(t *testing.T) {
+	transport := &flakyTransport{FakeTransport: NewFakeTransport()}
+	SetTransport(transport)
+	defer SetTransport(nil)
+	DisableRateLimits()
+
+	part := io.NewSectionReader(strings.NewReader("0123456789"), 2, 5)
+	info := &Info{source: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 5, Type: "private"}}}
+	deliver(OutMessage{OriginalMessage: info, Text: "part.001", FileStream: struct {
+		io.ReadSeeker
+		io.Closer
+	}{part, io.NopCloser(nil)}})
+
+	if len(transport.contents) != 2 || transport.contents[0] != "23456" || transport.contents[1] != "23456" {
+		t.Fatalf("not expected contents %q", transport.contents)
+	}
+	if sent := transport.Messages(); len(sent) != 1 || sent[0].FileName != "part.001" {
+		t.Fatalf("not expected messages %+v", sent)
+	}
+}
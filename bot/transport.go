@@ -0,0 +1,199 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Transport is the connection to telegram used by the whole bot,
+// FakeTransport replaces it to run conversations without telegram
+type Transport interface {
+	// send new message or document
+	Send(message tgbotapi.Chattable) (tgbotapi.Message, error)
+	// send new message or document to forum topic
+	SendToThread(message tgbotapi.Chattable, threadID int) (tgbotapi.Message, error)
+	// edit text or keyboard of sent message
+	Edit(edit tgbotapi.Chattable) (tgbotapi.Message, error)
+	AnswerCallback(answer tgbotapi.CallbackConfig) error
+	GetFileURL(fileID string) (string, error)
+	// start receiving updates by polling, channel is closed after ctx is done
+	Updates(ctx context.Context) tgbotapi.UpdatesChannel
+	// any other request, like chat action or inline query answer
+	Request(request tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	// request of method not supported by the library
+	MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error)
+	// user of the bot itself, username is used to find commands and mentions addressed to the bot
+	Self() tgbotapi.User
+}
+
+var transportMutex sync.Mutex
+var transport Transport
+
+// use given transport instead of telegram, must be called before the bot is started
+func SetTransport(t Transport) {
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+	transport = t
+}
+
+// transport set by SetTransport or connection to telegram with API_TOKEN
+func getTransport() Transport {
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+	if transport == nil {
+		transport = &telegramTransport{api: createBot()}
+	}
+	return transport
+}
+
+// production transport, requests go to telegram bot api
+type telegramTransport struct {
+	api *tgbotapi.BotAPI
+}
+
+func (t *telegramTransport) Send(message tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return t.api.Send(message)
+}
+
+func (t *telegramTransport) SendToThread(message tgbotapi.Chattable, threadID int) (tgbotapi.Message, error) {
+	endpoint, params, files, err := threadRequest(message, threadID)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	var resp *tgbotapi.APIResponse
+	if len(files) > 0 {
+		resp, err = t.api.UploadFiles(endpoint, params, files)
+	} else {
+		resp, err = t.api.MakeRequest(endpoint, params)
+	}
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	var sent tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &sent)
+	return sent, err
+}
+
+func (t *telegramTransport) Edit(edit tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return t.api.Send(edit)
+}
+
+func (t *telegramTransport) AnswerCallback(answer tgbotapi.CallbackConfig) error {
+	_, err := t.api.Request(answer)
+	return err
+}
+
+func (t *telegramTransport) GetFileURL(fileID string) (string, error) {
+	if API_URL == "" {
+		return t.api.GetFileDirectURL(fileID)
+	}
+	file, err := t.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(API_URL, "/") + "/file/bot" + API_TOKEN + "/" + file.FilePath, nil
+}
+
+func (t *telegramTransport) Updates(ctx context.Context) tgbotapi.UpdatesChannel {
+	// getUpdates does not work while webhook is set
+	if _, err := t.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		fmt.Println("error delete webhook ", err)
+	}
+	t.api.Client = &pollingClient{client: t.api.Client, ctx: ctx}
+
+	// Start polling Telegram for updates, raw json is decoded here
+	// to keep forum topics of messages which the library does not parse.
+	// The channel is not buffered, so only updates taken by handlers are confirmed.
+	updates := make(chan tgbotapi.Update)
+	go func() {
+		defer close(updates)
+		// Offsets are used to make sure Telegram knows we've handled previous
+		// values and we don't need them repeated.
+		offset := 0
+		for ctx.Err() == nil {
+			params := tgbotapi.Params{}
+			params.AddNonZero("offset", offset)
+			// Tell Telegram we should wait up to 30 seconds on each request for an
+			// update. This way we can get information just as quickly as making many
+			// frequent requests without having to send nearly as many.
+			params.AddNonZero("timeout", 30)
+
+			resp, err := t.api.MakeRequest("getUpdates", params)
+			var received []tgbotapi.Update
+			if err == nil {
+				received, err = decodeUpdates(resp.Result)
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				fmt.Println("error get updates ", err)
+				fmt.Println("retry in 3 seconds")
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second * 3):
+				}
+				continue
+			}
+
+			for _, update := range received {
+				if update.UpdateID < offset {
+					continue
+				}
+				select {
+				case updates <- update:
+					offset = update.UpdateID + 1
+				case <-ctx.Done():
+				}
+				if ctx.Err() != nil {
+					break
+				}
+			}
+		}
+
+		// confirm handled updates, otherwise telegram sends them again after restart
+		if offset != 0 {
+			params := tgbotapi.Params{}
+			params.AddNonZero("offset", offset)
+			params.AddNonZero("limit", 1)
+			if _, err := t.api.MakeRequest("getUpdates", params); err != nil {
+				fmt.Println("error confirm updates ", err)
+			}
+		}
+	}()
+	return updates
+}
+
+// aborts long polling when ctx is done, other requests and requests started later are not affected
+type pollingClient struct {
+	client tgbotapi.HTTPClient
+	ctx    context.Context
+}
+
+func (c *pollingClient) Do(request *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(request.URL.Path, "/getUpdates") && c.ctx.Err() == nil {
+		request = request.WithContext(c.ctx)
+	}
+	return c.client.Do(request)
+}
+
+func (t *telegramTransport) Request(request tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return t.api.Request(request)
+}
+
+func (t *telegramTransport) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	return t.api.MakeRequest(endpoint, params)
+}
+
+func (t *telegramTransport) Self() tgbotapi.User {
+	return t.api.Self
+}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// start update and send loops over fake transport
+func startFakeBot(t *testing.T) (*FakeTransport, chan OutMessage) {
+	fake := NewFakeTransport()
+	SetTransport(fake)
+	DisableRateLimits()
+	SetAccessList(map[int64]Role{1: RoleMember, 2: RoleGuest}, nil)
+	output := make(chan OutMessage, 10)
+	go Sender(output)
+	ctx, cancel := context.WithCancel(context.Background())
+	go RequestUpdates(ctx)
+	t.Cleanup(func() {
+		cancel()
+		fake.Close()
+		close(output)
+		SetTransport(nil)
+		SetAccessList(nil, nil)
+	})
+	return fake, output
+}
+
+func TestConversationOverFakeTransport(t *testing.T) {
+	resetRoutes()
+	fake, output := startFakeBot(t)
+
+	AddHandler(NewCommandMatcher("/hello"), func(message *Info) {
+		keyboard := MessageFilterKeyboard("en", NewAction("greet", map[string]string{"name": "Neo"}))
+		output <- OutMessage{OriginalMessage: message, Text: "Hi, what next?", UseInlineKeyboard: true, InlineKeyboard: keyboard}
+	})
+	RegisterAction("greet", RoleMember, func(message *Info, params map[string]string) {
+		output <- OutMessage{OriginalMessage: message, Text: message.Text + " " + params["name"], EditMessageID: message.source.MessageID}
+	}, "name")
+
+	requestID := fake.SendText(1, 1, "/hello")
+	messages, err := fake.WaitForMessages(1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messages[0].Text != "Hi, what next?" || messages[0].ReplyTo != requestID || messages[0].Keyboard == nil {
+		t.Fatalf("not expected reply %+v", messages[0])
+	}
+
+	fake.PressButton(1, 1, messages[0].MessageID, *messages[0].Keyboard.InlineKeyboard[0][0].CallbackData)
+	messages, err = fake.WaitForMessages(2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !messages[1].Edit || messages[1].MessageID != messages[0].MessageID || messages[1].Text != MessageSizeLimit+" Neo" {
+		t.Fatalf("not expected edit %+v", messages[1])
+	}
+	if answers := fake.CallbackAnswers(); len(answers) != 1 || answers[0].Alert {
+		t.Fatalf("not expected answers %+v", answers)
+	}
+}
+
+func TestExpiredButtonOverFakeTransport(t *testing.T) {
+	resetRoutes()
+	fake, _ := startFakeBot(t)
+
+	fake.PressButton(1, 1, 12345, MessageMore)
+	deadline := time.Now().Add(time.Second)
+	for len(fake.CallbackAnswers()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	answers := fake.CallbackAnswers()
+	if len(answers) != 1 || !answers[0].Alert {
+		t.Fatalf("expected alert about expired menu, got %+v", answers)
+	}
+	if len(fake.Messages()) != 0 {
+		t.Fatalf("nothing should be sent")
+	}
+}
+
+func TestForgedButtonOfGuestOverFakeTransport(t *testing.T) {
+	resetRoutes()
+	fake, output := startFakeBot(t)
+
+	pressed := make(chan string, 1)
+	RegisterAction("member_only", RoleMember, func(message *Info, params map[string]string) {
+		pressed <- params["id"]
+	}, "id")
+	RegisterAction("admin_only", RoleAdmin, func(message *Info, params map[string]string) {
+		pressed <- params["id"]
+	}, "id")
+
+	// guest was never shown the button, but can build its callback data
+	keyboard := ConfirmKeyboard("en", NewAction("member_only", map[string]string{"id": "7"}))
+	fake.PressButton(2, 2, 100, *keyboard.InlineKeyboard[0][0].CallbackData)
+	keyboard = ConfirmKeyboard("en", NewAction("admin_only", map[string]string{"id": "8"}))
+	fake.PressButton(1, 1, 101, *keyboard.InlineKeyboard[0][0].CallbackData)
+
+	deadline := time.Now().Add(time.Second)
+	for len(fake.CallbackAnswers()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	answers := fake.CallbackAnswers()
+	if len(answers) != 2 || !answers[0].Alert || !answers[1].Alert {
+		t.Fatalf("expected alerts about access, got %+v", answers)
+	}
+	select {
+	case id := <-pressed:
+		t.Fatalf("action ran for torrent %s", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if len(fake.Messages()) != 0 || len(output) != 0 {
+		t.Fatalf("nothing should be sent")
+	}
+}
+
+func TestMessageBoundButtonOverFakeTransport(t *testing.T) {
+	resetRoutes()
+	fake, output := startFakeBot(t)
+
+	AddHandler(NewCommandMatcher("/pick"), func(message *Info) {
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Red", "red")))
+		output <- OutMessage{OriginalMessage: message, Text: "Pick", UseInlineKeyboard: true, InlineKeyboard: keyboard, Action: NewAction("pick", map[string]string{"name": "Neo"})}
+	})
+	RegisterAction("pick", RoleMember, func(message *Info, params map[string]string) {
+		output <- OutMessage{OriginalMessage: message, Text: params["name"] + " " + message.Text}
+	})
+
+	fake.SendText(1, 1, "/pick")
+	messages, err := fake.WaitForMessages(1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.PressButton(1, 1, messages[0].MessageID, "red")
+	messages, err = fake.WaitForMessages(2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messages[1].Text != "Neo red" {
+		t.Fatalf("not expected reply %+v", messages[1])
+	}
+}
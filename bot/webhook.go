@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// updates are small, bigger requests are not from telegram
+const maxUpdateSize = 1 << 20
+
+// register webhook in telegram and receive updates with embedded http server until ctx is done,
+// publicUrl must point to path on listenAddress, random secret is used if secret is empty
+func ListenWebhook(ctx context.Context, publicUrl string, listenAddress string, path string, secret string) error {
+	if secret == "" {
+		var err error
+		if secret, err = randomSecret(); err != nil {
+			return fmt.Errorf("generate webhook secret: %w", err)
+		}
+		fmt.Println("WEBHOOK_SECRET is empty, using random secret until restart")
+	}
+	params := tgbotapi.Params{}
+	params.AddNonEmpty("url", publicUrl)
+	params.AddNonEmpty("secret_token", secret)
+	if _, err := getTransport().MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("set webhook: %w", err)
+	}
+
+	// not buffered, so update is accepted only when a handler takes it
+	updates := make(chan tgbotapi.Update)
+	mux := http.NewServeMux()
+	mux.Handle(path, webhookHandler(ctx, secret, updates))
+	server := &http.Server{Addr: listenAddress, Handler: mux}
+	go handleUpdates(ctx, updates)
+
+	go func() {
+		<-ctx.Done()
+		// requests are answered quickly, telegram retries rejected ones later
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			fmt.Println("error stop webhook server ", err)
+		}
+	}()
+
+	fmt.Println("Listen webhook on ", listenAddress+path)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// accept update json posted by telegram and pass it to updates channel,
+// after ctx is done updates are rejected, so telegram sends them again after restart
+func webhookHandler(ctx context.Context, secret string, updates chan<- tgbotapi.Update) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.Header.Get(secretTokenHeader)
+		// without secret anybody who knows the url could post updates from any user
+		if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			fmt.Println("webhook request with wrong secret token from ", r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUpdateSize))
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			fmt.Println("too big webhook request from ", r.RemoteAddr)
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		var update tgbotapi.Update
+		if err == nil {
+			err = json.Unmarshal(body, &update)
+		}
+		if err != nil {
+			fmt.Println("error decode webhook update ", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rememberTopics(body)
+
+		select {
+		case updates <- update:
+			w.WriteHeader(http.StatusOK)
+		case <-ctx.Done():
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// secret token of 64 hex characters, telegram allows letters, digits, _ and -
+func randomSecret() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
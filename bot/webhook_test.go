@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const updateJson = `{"update_id": 7, "message": {"message_id": 1, "chat": {"id": 42, "type": "private"}, "text": "matrix"}}`
+
+func postUpdate(t *testing.T, url string, secret string, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(secretTokenHeader, secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestWebhookAcceptsUpdate(t *testing.T) {
+	updates := make(chan tgbotapi.Update, 1)
+	server := httptest.NewServer(webhookHandler(context.Background(), "secret", updates))
+	defer server.Close()
+
+	resp := postUpdate(t, server.URL, "secret", updateJson)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	update := <-updates
+	if update.UpdateID != 7 || update.Message.Text != "matrix" || update.Message.Chat.ID != 42 {
+		t.Fatalf("not expected update %v", update)
+	}
+}
+
+func TestWebhookRejectsWithoutSecret(t *testing.T) {
+	updates := make(chan tgbotapi.Update, 1)
+	server := httptest.NewServer(webhookHandler(context.Background(), "", updates))
+	defer server.Close()
+
+	resp := postUpdate(t, server.URL, "", updateJson)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("update should not be passed")
+	}
+}
+
+func TestWebhookRejectsTooBigBody(t *testing.T) {
+	updates := make(chan tgbotapi.Update, 1)
+	server := httptest.NewServer(webhookHandler(context.Background(), "secret", updates))
+	defer server.Close()
+
+	body := `{"update_id": 7, "message": {"text": "` + strings.Repeat("a", maxUpdateSize) + `"}}`
+	resp := postUpdate(t, server.URL, "secret", body)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", resp.StatusCode)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("update should not be passed")
+	}
+}
+
+func TestRandomSecret(t *testing.T) {
+	first, err := randomSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := randomSecret()
+	if len(first) != 64 || first == second {
+		t.Fatalf("not expected secrets %s %s", first, second)
+	}
+}
+
+func TestWebhookRejectsWrongSecret(t *testing.T) {
+	updates := make(chan tgbotapi.Update, 1)
+	server := httptest.NewServer(webhookHandler(context.Background(), "secret", updates))
+	defer server.Close()
+
+	resp := postUpdate(t, server.URL, "other", updateJson)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("update should not be passed")
+	}
+}
+
+func TestWebhookRejectsInvalidJson(t *testing.T) {
+	updates := make(chan tgbotapi.Update, 1)
+	server := httptest.NewServer(webhookHandler(context.Background(), "secret", updates))
+	defer server.Close()
+
+	resp := postUpdate(t, server.URL, "secret", "{")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestWebhookRejectsGet(t *testing.T) {
+	updates := make(chan tgbotapi.Update, 1)
+	server := httptest.NewServer(webhookHandler(context.Background(), "secret", updates))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", resp.StatusCode)
+	}
+}
+
+func TestWebhookRejectsUpdateAfterStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	updates := make(chan tgbotapi.Update)
+	server := httptest.NewServer(webhookHandler(ctx, "secret", updates))
+	defer server.Close()
+
+	resp := postUpdate(t, server.URL, "secret", updateJson)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", resp.StatusCode)
+	}
+}
@@ -2,14 +2,17 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
 	TorrentFileFolder      string // folder to store torrent files, which will be downloaded by transmission as a result
 	TelegramBotToken       string
+	TelegramApiUrl         string // self-hosted bot api server, allows uploads up to 2000 MB
 	RuTrackerUserName      string
 	RuTrackerPassword      string
 	ActiveTorrentFilesPath string // folder which currently downloading, transmission will move torrent files to this folder
@@ -23,6 +26,15 @@ type Config struct {
 	JackettApiKey          string
 	JackettPortFrom        int
 	JackettPortTo          int
+	WebhookUrl             string // public url of webhook, bot uses long polling if empty
+	WebhookListen          string // address of embedded http server for webhook
+	WebhookPath            string
+	WebhookSecret          string           // compared with X-Telegram-Bot-Api-Secret-Token header, random one is used if empty
+	DataFolder             string           // folder for bot state like keyboard callbacks, current folder by default
+	ShutdownSeconds        int              // time to finish work after SIGTERM, docker kills the bot after 10 seconds
+	MinFreeSpaceGB         int              // bot warns before adding torrent which leaves less free space
+	AllowedUsers           map[int64]string // telegram user id to role name, from ALLOWED_USERS="123:admin,456:member"
+	AllowedChats           map[int64]string // telegram chat id to role name, from ALLOWED_CHATS
 }
 
 func Read() (Config, error) {
@@ -35,6 +47,7 @@ func Read() (Config, error) {
 	result.TransmissionPortTo = parseIntOrDefault(os.Getenv("TRANSMISSION_PORT_TO"), 0)
 	result.TorrentFileFolder = os.Getenv("TORRENT_FOLDER")
 	result.TelegramBotToken = os.Getenv("TELEGRAM_TOKEN")
+	result.TelegramApiUrl = os.Getenv("TELEGRAM_API_URL")
 	if result.TorrentFileFolder == "" {
 		return result, errors.New("no torrent folder")
 	}
@@ -50,7 +63,32 @@ func Read() (Config, error) {
 	result.ActiveTorrentFilesPath = os.Getenv("ACTIVE_TORRENT_FILES_PATH")
 	result.FinishedFolder = os.Getenv("FINISHED_FOLDER")
 	result.KVDBToken = os.Getenv("KVDB_TOKEN")
+	result.WebhookUrl = os.Getenv("WEBHOOK_URL")
+	result.WebhookListen = os.Getenv("WEBHOOK_LISTEN")
+	if result.WebhookListen == "" {
+		result.WebhookListen = ":8080"
+	}
+	result.WebhookPath = os.Getenv("WEBHOOK_PATH")
+	if result.WebhookPath == "" {
+		result.WebhookPath = "/telegram"
+	}
+	result.WebhookSecret = os.Getenv("WEBHOOK_SECRET")
+	result.DataFolder = os.Getenv("DATA_FOLDER")
+	if result.DataFolder == "" {
+		result.DataFolder = "."
+	}
+	result.ShutdownSeconds = parseIntOrDefault(os.Getenv("SHUTDOWN_TIMEOUT"), 8)
+	result.MinFreeSpaceGB = parseIntOrDefault(os.Getenv("MIN_FREE_SPACE_GB"), 5)
 	result.GeminiApiKey = os.Getenv("GEMINI_AI_API_TOKEN")
+	var err error
+	result.AllowedUsers, err = parseAccessList(os.Getenv("ALLOWED_USERS"))
+	if err != nil {
+		return result, err
+	}
+	result.AllowedChats, err = parseAccessList(os.Getenv("ALLOWED_CHATS"))
+	if err != nil {
+		return result, err
+	}
 	if result.RuTrackerUserName == "" || result.RuTrackerPassword == "" || result.KVDBToken == "" {
 		return result, errors.New("missing arguments")
 	}
@@ -61,6 +99,28 @@ func CreateFilePath(torrentFolder string, fileName string) string {
 	return filepath.Join(torrentFolder, fileName)
 }
 
+// parse list like "123:admin,-100456:member,789", role is "member" if omitted
+func parseAccessList(str string) (map[int64]string, error) {
+	result := make(map[int64]string)
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		idStr, role, found := strings.Cut(item, ":")
+		if !found {
+			role = "member"
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(idStr), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id in access list %q: %w", item, err)
+		}
+		result[id] = strings.ToLower(strings.TrimSpace(role))
+	}
+	return result, nil
+}
+
 func parseIntOrDefault(str string, defaultValue int) int {
 	num, err := strconv.Atoi(str)
 	if err != nil {
@@ -72,3 +72,30 @@ func TestCreateFilePath4(t *testing.T) {
 		t.Fatalf("expected %v, got %v", expected, actual)
 	}
 }
+
+func TestParseAccessList(t *testing.T) {
+	actual, err := parseAccessList("123:admin, -100456:guest,789")
+	if err != nil {
+		t.Fatalf("not expected error %v", err)
+	}
+	if len(actual) != 3 {
+		t.Fatalf("expected 3 entries, got %v", actual)
+	}
+	if actual[123] != "admin" || actual[-100456] != "guest" || actual[789] != "member" {
+		t.Fatalf("not expected %v", actual)
+	}
+}
+
+func TestParseAccessListEmpty(t *testing.T) {
+	actual, err := parseAccessList("")
+	if err != nil || len(actual) != 0 {
+		t.Fatalf("expected empty list, got %v %v", actual, err)
+	}
+}
+
+func TestParseAccessListInvalid(t *testing.T) {
+	_, err := parseAccessList("abc:admin")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+}
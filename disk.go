@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/telegram-command-reader/bot"
+	"github.com/telegram-command-reader/i18n"
+	"github.com/telegram-command-reader/operations"
+	transmission "github.com/telegram-command-reader/operations/transmission"
+)
+
+// send free space of torrent and finished folders and of download dir of transmission
+func showDiskSpace(message *bot.Info, outputChannel chan bot.OutMessage) {
+	lang := message.Language()
+	var result strings.Builder
+	result.WriteString(message.T(i18n.DiskHeader) + "\n\n")
+
+	for _, folder := range []struct{ name, path string }{{"TORRENT_FOLDER", torrentFolderPath}, {"FINISHED_FOLDER", finishedFolderPath}} {
+		if folder.path == "" {
+			continue
+		}
+		space, err := operations.LocalDiskSpace(folder.path)
+		result.WriteString(formatDiskSpace(folder.name, folder.path, space, err, lang))
+	}
+
+	ctx := message.Context()
+	dir, err := transmission.DownloadDir(ctx)
+	var space operations.DiskSpace
+	if err == nil {
+		space.Free, space.Total, err = transmission.FreeSpace(ctx, dir)
+	}
+	result.WriteString(formatDiskSpace("Transmission", dir, space, err, lang))
+
+	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: result.String(), Html: true}
+}
+
+func formatDiskSpace(name string, path string, space operations.DiskSpace, err error, lang string) string {
+	if err != nil {
+		return i18n.T(lang, i18n.DiskError, name, html.EscapeString(path), html.EscapeString(err.Error()))
+	}
+	total := "?"
+	if space.Total > 0 {
+		total = transmission.FormatBytes(space.Total)
+	}
+	return i18n.T(lang, i18n.DiskItem, name, html.EscapeString(path), transmission.FormatBytes(space.Free), total)
+}
+
+// free bytes where transmission downloads, or in FINISHED_FOLDER if transmission does not tell
+func downloadFreeSpace(ctx context.Context) (int64, error) {
+	dir, err := transmission.DownloadDir(ctx)
+	if err == nil {
+		var free int64
+		if free, _, err = transmission.FreeSpace(ctx, dir); err == nil {
+			return free, nil
+		}
+	}
+	fmt.Println("transmission free space error ", err)
+	if finishedFolderPath == "" {
+		return 0, err
+	}
+	space, err := operations.LocalDiskSpace(finishedFolderPath)
+	return space.Free, err
+}
+
+// compare size of torrent with free space before adding it, warns if little space is left,
+// returns false and replies if the torrent does not fit, torrent of unknown size is allowed
+func checkFreeSpace(message *bot.Info, size int64, outputChannel chan bot.OutMessage) bool {
+	if size <= 0 {
+		return true
+	}
+	free, err := downloadFreeSpace(message.Context())
+	if err != nil {
+		fmt.Println("free space error ", err)
+		return true
+	}
+
+	switch operations.CheckSpace(size, free, minFreeSpace) {
+	case operations.SpaceNotEnough:
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NotEnoughSpace, transmission.FormatBytes(size), transmission.FormatBytes(free))}
+		return false
+	case operations.SpaceLow:
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.LowSpace, transmission.FormatBytes(size), transmission.FormatBytes(free-size))}
+	}
+	return true
+}
+
+// save downloaded .torrent file to destination if its content fits on the disk, true if it is saved
+func saveTorrentIfFits(message *bot.Info, stream io.ReadCloser, destination string, outputChannel chan bot.OutMessage) bool {
+	data, torrent, err := operations.ReadTorrent(stream)
+	if err != nil {
+		fmt.Println("read torrent file error ", err)
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidTorrentFile, err)}
+		return false
+	}
+	if !checkFreeSpace(message, torrent.TotalSize, outputChannel) {
+		return false
+	}
+	if err := os.WriteFile(destination, data, 0644); err != nil {
+		fmt.Println("save torrent file error ", err)
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DownloadError, err)}
+		return false
+	}
+	fmt.Println("saved torrent file to ", destination)
+	return true
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/telegram-command-reader/bot"
+	"github.com/telegram-command-reader/i18n"
+	"github.com/telegram-command-reader/operations"
+)
+
+// message about finished download with button to send its files, if finished folder is known
+func finishedMessage(originalMessage *bot.Info, name string) bot.OutMessage {
+	reply := bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.Finished, name)}
+	if finishedFolderPath != "" && name == filepath.Base(name) {
+		reply.UseInlineKeyboard = true
+		reply.InlineKeyboard = bot.SendFilesKeyboard(originalMessage.Language(), bot.NewAction(actionSendFiles, map[string]string{"name": name}))
+	}
+	return reply
+}
+
+// upload file or folder of finished download to the chat, big files are split, many files are zipped
+func sendFinishedFiles(message *bot.Info, name string, outputChannel chan bot.OutMessage) {
+	path, err := operations.FolderEntry(finishedFolderPath, name)
+	if err != nil {
+		fmt.Printf("refused to send %q: %v\n", name, err)
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NoFilesToSend)}
+		return
+	}
+
+	bot.SendTypingStatus(message)
+	parts, err := operations.PrepareUpload(path, bot.MaxUploadSize())
+	if errors.Is(err, operations.ErrTooBigToUpload) {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.TooBigToSend)}
+		return
+	}
+	if err != nil {
+		fmt.Println("prepare upload error ", err)
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.UploadError, err)}
+		return
+	}
+	if len(parts) == 0 {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NoFilesToSend)}
+		return
+	}
+
+	for _, part := range parts {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: part.Name, FileStream: part.Stream}
+	}
+}
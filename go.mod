@@ -1,6 +1,6 @@
 module github.com/telegram-command-reader
 
-go 1.20
+go 1.21
 
 require github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
 
@@ -22,7 +22,6 @@ require (
 	github.com/googleapis/enterprise-certificate-proxy v0.3.2 // indirect
 	github.com/googleapis/gax-go/v2 v2.12.2 // indirect
 	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
-	github.com/hekmon/cunits/v2 v2.1.0 // indirect
 	github.com/huandu/xstrings v1.3.2 // indirect
 	go.opencensus.io v0.24.0 // indirect
 	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.49.0 // indirect
@@ -54,6 +53,7 @@ require (
 
 require (
 	github.com/google/generative-ai-go v0.10.0
+	github.com/hekmon/cunits/v2 v2.1.0
 	github.com/hekmon/transmissionrpc/v3 v3.0.0
 	github.com/pkg/errors v0.9.1
 )
@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Key identifies a user-facing string in the bundles
+type Key string
+
+const (
+	Russian = "ru"
+	English = "en"
+)
+
+// used when user has no setting and telegram language is not supported
+var DefaultLanguage = Russian
+
+var bundles = map[string]map[Key]string{
+	Russian: russian,
+	English: english,
+}
+
+// languages which can be chosen by user
+func Languages() []string {
+	return []string{Russian, English}
+}
+
+func Supported(lang string) bool {
+	_, ok := bundles[lang]
+	return ok
+}
+
+// language of the bundle for telegram language_code like "en-US", default if not supported
+func Match(languageCode string) string {
+	lang := strings.ToLower(languageCode)
+	if index := strings.IndexAny(lang, "-_"); index > 0 {
+		lang = lang[:index]
+	}
+	if Supported(lang) {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// translate key, args are formatted with fmt.Sprintf,
+// default language is used if the key is missing in the bundle
+func T(lang string, key Key, args ...interface{}) string {
+	text, ok := bundles[lang][key]
+	if !ok {
+		text, ok = bundles[DefaultLanguage][key]
+	}
+	if !ok {
+		text = string(key)
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// languages chosen by users with /lang
+type userLanguages struct {
+	mutex     sync.Mutex
+	path      string
+	languages map[int64]string
+}
+
+var users = &userLanguages{languages: make(map[int64]string)}
+
+// load languages chosen by users, missing file is not an error
+func LoadUserLanguages(path string) error {
+	users.mutex.Lock()
+	defer users.mutex.Unlock()
+	users.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &users.languages)
+}
+
+// language chosen by the user, false if user has not chosen
+func UserLanguage(userID int64) (string, bool) {
+	users.mutex.Lock()
+	defer users.mutex.Unlock()
+	lang, ok := users.languages[userID]
+	return lang, ok
+}
+
+// remember language of the user and save it to file
+func SetUserLanguage(userID int64, lang string) error {
+	if !Supported(lang) {
+		return fmt.Errorf("unsupported language %s", lang)
+	}
+	users.mutex.Lock()
+	defer users.mutex.Unlock()
+	users.languages[userID] = lang
+	if users.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(users.languages)
+	if err != nil {
+		return err
+	}
+	tmpPath := users.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, users.path)
+}
@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundlesHaveSameKeys(t *testing.T) {
+	for lang, bundle := range bundles {
+		for key := range bundles[DefaultLanguage] {
+			if _, ok := bundle[key]; !ok {
+				t.Errorf("%s: missing %s", lang, key)
+			}
+		}
+		for key := range bundle {
+			if _, ok := bundles[DefaultLanguage][key]; !ok {
+				t.Errorf("%s: %s is not in default bundle", lang, key)
+			}
+		}
+	}
+}
+
+func TestBundlesHaveSameFormatVerbs(t *testing.T) {
+	for key, text := range bundles[DefaultLanguage] {
+		if strings.Count(text, "%") != strings.Count(english[key], "%") {
+			t.Errorf("%s: different arguments %q %q", key, text, english[key])
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := map[string]string{
+		"en":    English,
+		"en-US": English,
+		"RU":    Russian,
+		"de":    DefaultLanguage,
+		"":      DefaultLanguage,
+	}
+	for code, expected := range cases {
+		if actual := Match(code); actual != expected {
+			t.Errorf("%s: expected %s, actual %s", code, expected, actual)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if actual := T(English, Saved, "Matrix"); actual != "Saved: Matrix" {
+		t.Fatalf("not expected %s", actual)
+	}
+	if actual := T("de", WhatToDo); actual != russian[WhatToDo] {
+		t.Fatalf("expected fallback to default language, got %s", actual)
+	}
+	if actual := T(English, Key("missing")); actual != "missing" {
+		t.Fatalf("expected key for missing text, got %s", actual)
+	}
+}
+
+func TestUserLanguageSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "languages.json")
+	if err := LoadUserLanguages(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetUserLanguage(7, English); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetUserLanguage(7, "de"); err == nil {
+		t.Fatalf("unsupported language was saved")
+	}
+
+	users = &userLanguages{languages: make(map[int64]string)}
+	if err := LoadUserLanguages(path); err != nil {
+		t.Fatal(err)
+	}
+	if lang, ok := UserLanguage(7); !ok || lang != English {
+		t.Fatalf("expected en, got %s %v", lang, ok)
+	}
+}
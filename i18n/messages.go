@@ -0,0 +1,563 @@
+package i18n
+
+const (
+	// keyboards
+	ButtonEverywhere       Key = "button_everywhere"
+	ButtonMovies           Key = "button_movies"
+	ButtonSeries           Key = "button_series"
+	ButtonAudiobooks       Key = "button_audiobooks"
+	ButtonBooks            Key = "button_books"
+	ButtonTorrentFile      Key = "button_torrent_file"
+	ButtonToServer         Key = "button_to_server"
+	ButtonDownloadOnServer Key = "button_download_on_server"
+	ButtonMore             Key = "button_more"
+	ButtonOtherProviders   Key = "button_other_providers"
+	ButtonSizeLimit        Key = "button_size_limit"
+	ButtonOpenRutracker    Key = "button_open_rutracker"
+	ButtonDownload         Key = "button_download"
+	ButtonCancel           Key = "button_cancel"
+	ButtonSendFiles        Key = "button_send_files"
+	ButtonSortName         Key = "button_sort_name"
+	ButtonSortProgress     Key = "button_sort_progress"
+	ButtonSortSize         Key = "button_sort_size"
+	ButtonSortAdded        Key = "button_sort_added"
+	ButtonPrevious         Key = "button_previous"
+	ButtonNext             Key = "button_next"
+	ButtonStop             Key = "button_stop"
+	ButtonStart            Key = "button_start"
+	ButtonStartNow         Key = "button_start_now"
+	ButtonVerify           Key = "button_verify"
+	ButtonReannounce       Key = "button_reannounce"
+	ButtonDeleteTorrent    Key = "button_delete_torrent"
+	ButtonDeleteWithFiles  Key = "button_delete_with_files"
+	ButtonInfoSummary      Key = "button_info_summary"
+	ButtonInfoFiles        Key = "button_info_files"
+	ButtonInfoPeers        Key = "button_info_peers"
+	ButtonInfoTrackers     Key = "button_info_trackers"
+	ButtonFilesWanted      Key = "button_files_wanted"
+	ButtonPriorityHigh     Key = "button_priority_high"
+	ButtonPriorityNormal   Key = "button_priority_normal"
+	ButtonPriorityLow      Key = "button_priority_low"
+	ButtonFilesAll         Key = "button_files_all"
+	ButtonFilesNone        Key = "button_files_none"
+	ButtonTurtleOn         Key = "button_turtle_on"
+	ButtonTurtleOff        Key = "button_turtle_off"
+	ButtonUnlimited        Key = "button_unlimited"
+
+	// questions and replies
+	WhatToDo            Key = "what_to_do"
+	WhereToSearch       Key = "where_to_search"
+	AskSizeLimit        Key = "ask_size_limit"
+	BadSizeLimit        Key = "bad_size_limit"
+	ConversationTimeout Key = "conversation_timeout"
+	Cancelled           Key = "cancelled"
+	NothingToCancel     Key = "nothing_to_cancel"
+	Saved               Key = "saved"
+	NothingSaved        Key = "nothing_saved"
+	Deleted             Key = "deleted"
+	DeletedWithFiles    Key = "deleted_with_files"
+	ConfirmDelete       Key = "confirm_delete"
+	DownloadingMagnet   Key = "downloading_magnet"
+	StartLoading        Key = "start_loading"
+	Finished            Key = "finished"
+	QueueStats          Key = "queue_stats"
+	AiAdvice            Key = "ai_advice"
+	Scheduled           Key = "scheduled"
+	ChooseLanguage      Key = "choose_language"
+	LanguageChanged     Key = "language_changed"
+	MagnetSummary       Key = "magnet_summary"
+	TorrentSummary      Key = "torrent_summary"
+	Unnamed             Key = "unnamed"
+	Yes                 Key = "yes"
+	No                  Key = "no"
+	NoDownloading       Key = "no_downloading"
+	NoFinished          Key = "no_finished"
+	ListPage            Key = "list_page"
+	ControlResult       Key = "control_result"
+	ControlAllResult    Key = "control_all_result"
+	FilesHeader         Key = "files_header"
+	FilesHintWanted     Key = "files_hint_wanted"
+	FilesHintPriority   Key = "files_hint_priority"
+	NoTorrentFiles      Key = "no_torrent_files"
+	SpeedStatus         Key = "speed_status"
+	SpeedUnlimited      Key = "speed_unlimited"
+	ScheduleAdded       Key = "schedule_added"
+	ScheduleRemoved     Key = "schedule_removed"
+	NoSchedules         Key = "no_schedules"
+	SchedulesHeader     Key = "schedules_header"
+	DiskHeader          Key = "disk_header"
+	DiskItem            Key = "disk_item"
+	LowSpace            Key = "low_space"
+
+	// access and menus
+	MenuExpired     Key = "menu_expired"
+	PrivateBot      Key = "private_bot"
+	PrivateBotShort Key = "private_bot_short"
+	SeePrivateChat  Key = "see_private_chat"
+
+	// errors
+	ErrorWithStack          Key = "error_with_stack"
+	InvalidTorrentID        Key = "invalid_torrent_id"
+	NoMagnet                Key = "no_magnet"
+	AddTorrentError         Key = "add_torrent_error"
+	DownloadError           Key = "download_error"
+	DeleteError             Key = "delete_error"
+	DeleteFailed            Key = "delete_failed"
+	WaitStartError          Key = "wait_start_error"
+	WaitFinishError         Key = "wait_finish_error"
+	StoppedWatching         Key = "stopped_watching"
+	SearchError             Key = "search_error"
+	NoResults               Key = "no_results"
+	NoResultsWithSize       Key = "no_results_with_size"
+	TransmissionError       Key = "transmission_error"
+	TransmissionUnavailable Key = "transmission_unavailable"
+	UnknownLanguage         Key = "unknown_language"
+	InvalidMagnet           Key = "invalid_magnet"
+	NotTorrentFile          Key = "not_torrent_file"
+	InvalidTorrentFile      Key = "invalid_torrent_file"
+	UploadError             Key = "upload_error"
+	TooBigToSend            Key = "too_big_to_send"
+	NoFilesToSend           Key = "no_files_to_send"
+	ControlError            Key = "control_error"
+	AdminOnly               Key = "admin_only"
+	BadSpeed                Key = "bad_speed"
+	BadSchedule             Key = "bad_schedule"
+	NoSuchSchedule          Key = "no_such_schedule"
+	DiskError               Key = "disk_error"
+	NotEnoughSpace          Key = "not_enough_space"
+
+	// help
+	Welcome         Key = "welcome"
+	HelpHeader      Key = "help_header"
+	HelpHelp        Key = "help_help"
+	HelpDownload    Key = "help_download"
+	HelpSaved       Key = "help_saved"
+	HelpDownloading Key = "help_downloading"
+	HelpFinished    Key = "help_finished"
+	HelpVersion     Key = "help_version"
+	HelpStats       Key = "help_stats"
+	HelpLang        Key = "help_lang"
+	HelpTopic       Key = "help_topic"
+	HelpCancel      Key = "help_cancel"
+	HelpMagnet      Key = "help_magnet"
+	HelpTopicUrl    Key = "help_topic_url"
+	HelpControl     Key = "help_control"
+	HelpControlAll  Key = "help_control_all"
+	HelpInfo        Key = "help_info"
+	HelpFiles       Key = "help_files"
+	HelpDelete      Key = "help_delete"
+	HelpSpeed       Key = "help_speed"
+	HelpSpeedSet    Key = "help_speed_set"
+	HelpSchedule    Key = "help_schedule"
+	HelpScheduleAdd Key = "help_schedule_add"
+	HelpUnschedule  Key = "help_unschedule"
+	HelpDisk        Key = "help_disk"
+
+	// formatter
+	SearchItem         Key = "search_item"
+	ArticleDescription Key = "article_description"
+	ArticleText        Key = "article_text"
+	JackettDescription Key = "jackett_description"
+	JackettText        Key = "jackett_text"
+	JackettItem        Key = "jackett_item"
+	TorrentFileItem    Key = "torrent_file_item"
+	MoreFiles          Key = "more_files"
+	InfoSummary        Key = "info_summary"
+	InfoError          Key = "info_error"
+	InfoFileItem       Key = "info_file_item"
+	InfoPeerItem       Key = "info_peer_item"
+	InfoTrackerItem    Key = "info_tracker_item"
+	InfoTrackerError   Key = "info_tracker_error"
+	NoPeers            Key = "no_peers"
+	NoTrackers         Key = "no_trackers"
+	DownloadingItem    Key = "downloading_item"
+	FinishedItem       Key = "finished_item"
+	ScheduleItem       Key = "schedule_item"
+
+	// torrent status and progress
+	StatusStopped      Key = "status_stopped"
+	StatusCheckWait    Key = "status_check_wait"
+	StatusCheck        Key = "status_check"
+	StatusDownloadWait Key = "status_download_wait"
+	StatusDownload     Key = "status_download"
+	StatusSeedWait     Key = "status_seed_wait"
+	StatusSeed         Key = "status_seed"
+	StatusIsolated     Key = "status_isolated"
+	StatusStalled      Key = "status_stalled"
+	StatusUnknown      Key = "status_unknown"
+	StatusCount        Key = "status_count"
+	ETAUnknown         Key = "eta_unknown"
+	ProgressError      Key = "progress_error"
+	ProgressSpeed      Key = "progress_speed"
+	DownloadSpeed      Key = "download_speed"
+)
+
+var russian = map[Key]string{
+	ButtonEverywhere:       "Везде",
+	ButtonMovies:           "Фильмы",
+	ButtonSeries:           "Сериалы",
+	ButtonAudiobooks:       "Аудиокниги",
+	ButtonBooks:            "Книги",
+	ButtonTorrentFile:      "Скачать торрент файл",
+	ButtonToServer:         "На сервер",
+	ButtonDownloadOnServer: "Скачать на сервер",
+	ButtonMore:             "Следующие результаты",
+	ButtonOtherProviders:   "Искать в других местах",
+	ButtonSizeLimit:        "Ограничить размер",
+	ButtonOpenRutracker:    "Открыть на RuTracker",
+	ButtonDownload:         "Скачать",
+	ButtonCancel:           "Отмена",
+	ButtonSendFiles:        "Прислать файлы",
+	ButtonSortName:         "По имени",
+	ButtonSortProgress:     "По прогрессу",
+	ButtonSortSize:         "По размеру",
+	ButtonSortAdded:        "По дате",
+	ButtonPrevious:         "◀ Назад",
+	ButtonNext:             "Вперед ▶",
+	ButtonStop:             "⏸ Остановить",
+	ButtonStart:            "▶ Запустить",
+	ButtonStartNow:         "⏩ Без очереди",
+	ButtonVerify:           "Проверить",
+	ButtonReannounce:       "Обновить пиров",
+	ButtonDeleteTorrent:    "Удалить только торрент",
+	ButtonDeleteWithFiles:  "Удалить торрент и файлы",
+	ButtonInfoSummary:      "Сводка",
+	ButtonInfoFiles:        "Файлы",
+	ButtonInfoPeers:        "Пиры",
+	ButtonInfoTrackers:     "Трекеры",
+	ButtonFilesWanted:      "✅ Скачивать",
+	ButtonPriorityHigh:     "⬆ Высокий",
+	ButtonPriorityNormal:   "Обычный",
+	ButtonPriorityLow:      "⬇ Низкий",
+	ButtonFilesAll:         "Все",
+	ButtonFilesNone:        "Ничего",
+	ButtonTurtleOn:         "🐢 Включить черепаху",
+	ButtonTurtleOff:        "Выключить черепаху",
+	ButtonUnlimited:        "Снять ограничения",
+
+	WhatToDo:            "Что делаем?",
+	WhereToSearch:       "Где искать?",
+	AskSizeLimit:        "Максимальный размер? Например: 700 MB или 10 GB. /cancel - отмена",
+	BadSizeLimit:        "Не понял размер, например: 700 MB или 10 GB. /cancel - отмена",
+	ConversationTimeout: "Не дождался ответа, начните заново",
+	Cancelled:           "Отменено",
+	NothingToCancel:     "Нечего отменять",
+	Saved:               "Сохранено: %s",
+	NothingSaved:        "Ничего не сохранено",
+	Deleted:             "Удалено: %s",
+	DeletedWithFiles:    "Удалено вместе с файлами: %s",
+	ConfirmDelete:       "Удалить <b>%s</b>, %s?",
+	DownloadingMagnet:   "Загружаю по magnet ссылке",
+	StartLoading:        "Начал загрузку: %s",
+	Finished:            "%s загружен",
+	QueueStats:          "В очереди: %d сообщений в %d чатах\nСамая длинная очередь: %d (чат %d)\nОтправлено: %d, ограничено: %d",
+	AiAdvice:            "<b>Совет искусственного интеллекта:</b>\n%s",
+	Scheduled:           "Поставил в очередь",
+	ChooseLanguage:      "Выберите язык",
+	LanguageChanged:     "Язык: русский",
+	MagnetSummary:       "<b>%s</b>\nInfohash: <code>%s</code>\nТрекеры: %s\n\nСкачать на сервер?",
+	TorrentSummary:      "<b>%s</b>\nРазмер: %s, файлов: %d\nРазмер части: %s, приватный: %s\nInfohash: <code>%s</code>\nТрекеры: %s\n\nСамые большие файлы:\n%s\nСкачать на сервер?",
+	Unnamed:             "Без названия",
+	Yes:                 "да",
+	No:                  "нет",
+	NoDownloading:       "Сейчас ничего не скачивается",
+	NoFinished:          "Нет скачанных торрентов",
+	ListPage:            "Страница %d из %d",
+	ControlResult:       "<b>%s</b>: %s",
+	ControlAllResult:    "Все торренты: %s",
+	FilesHeader:         "<b>%s</b>\nВыбрано файлов: %d из %d, %s\n%s",
+	FilesHintWanted:     "Нажмите на файл, чтобы скачать или пропустить его",
+	FilesHintPriority:   "Нажмите на файл, чтобы задать приоритет: %s",
+	NoTorrentFiles:      "Список файлов еще не известен",
+	SpeedStatus:         "Загрузка: %s\nОтдача: %s\nЧерепаха: %s, ↓ %s ↑ %s",
+	SpeedUnlimited:      "без ограничений",
+	ScheduleAdded:       "Расписание добавлено: %s",
+	ScheduleRemoved:     "Расписание удалено: %s",
+	NoSchedules:         "Расписаний нет. Добавьте: /schedule 18:00-23:00 2MB",
+	SchedulesHeader:     "Расписания скорости, действует первое подходящее:",
+	DiskHeader:          "Место на диске:",
+	DiskItem:            "<b>%s</b> <code>%s</code>\nсвободно %s из %s\n",
+	LowSpace:            "Внимание: после загрузки %s останется только %s свободного места",
+
+	MenuExpired:     "Меню устарело, повторите запрос",
+	PrivateBot:      "Это закрытый бот. Попросите владельца добавить ваш id %d",
+	PrivateBotShort: "Это закрытый бот",
+	SeePrivateChat:  "Готово, ответ в личном чате с ботом",
+
+	ErrorWithStack:          "Ошибка: %v, стек: %s",
+	InvalidTorrentID:        "Неверный id торрента %s",
+	NoMagnet:                "Повторите поиск, нет ссылки для id %s",
+	AddTorrentError:         "Ошибка добавления торрента: %v",
+	DownloadError:           "Не удалось скачать: %v",
+	DeleteError:             "Ошибка удаления торрента: %v",
+	DeleteFailed:            "Не удалось удалить торрент",
+	WaitStartError:          "Ждал начала загрузки, но ошибка: %v",
+	WaitFinishError:         "Ждал окончания загрузки, но ошибка: %v",
+	StoppedWatching:         "Перестал следить за торрентом: %v",
+	SearchError:             "Ошибка поиска: %v",
+	NoResults:               "Ничего не найдено",
+	NoResultsWithSize:       "Ничего не найдено такого размера",
+	TransmissionError:       "Ошибка подключения к transmission: %v",
+	TransmissionUnavailable: "Не удалось подключиться к transmission",
+	UnknownLanguage:         "Неизвестный язык, доступны: %s",
+	InvalidMagnet:           "Не удалось разобрать magnet ссылку: %v",
+	NotTorrentFile:          "Это не .torrent файл, пришлите торрент-файл или magnet ссылку",
+	InvalidTorrentFile:      "Не удалось прочитать .torrent файл: %v",
+	UploadError:             "Не удалось отправить файлы: %v",
+	TooBigToSend:            "Слишком большая загрузка, чтобы прислать ее в телеграм",
+	NoFilesToSend:           "Нет файлов для отправки",
+	ControlError:            "Не получилось: %v",
+	AdminOnly:               "Это могут только администраторы",
+	BadSpeed:                "Не понял скорость: %v. Пример: /speed 2MB 500KB",
+	BadSchedule:             "Не понял расписание: %v. Пример: /schedule 18:00-23:00 2MB 500KB",
+	NoSuchSchedule:          "Нет расписания %s",
+	DiskError:               "<b>%s</b> <code>%s</code>\nошибка: %s\n",
+	NotEnoughSpace:          "Не хватит места: нужно %s, свободно %s",
+
+	Welcome:         "Привет! Я ищу торренты на RuTracker и в Jackett и скачиваю их на сервер.",
+	HelpHeader:      "Напишите название фильма или книги для поиска, или пришлите .torrent файл.",
+	HelpHelp:        "список команд",
+	HelpDownload:    "скачать результат поиска Jackett",
+	HelpSaved:       "сохраненные запросы",
+	HelpDownloading: "торренты в загрузке",
+	HelpFinished:    "загруженные торренты",
+	HelpVersion:     "версия бота",
+	HelpStats:       "очереди исходящих сообщений",
+	HelpLang:        "выбрать язык",
+	HelpTopic:       "скачать раздачу RuTracker",
+	HelpCancel:      "отменить текущий вопрос",
+	HelpMagnet:      "скачать по magnet ссылке",
+	HelpTopicUrl:    "скачать раздачу по ссылке",
+	HelpControl:     "остановить, запустить, запустить без очереди, проверить или обновить пиров торрента",
+	HelpControlAll:  "то же для всех торрентов",
+	HelpInfo:        "подробности торрента: файлы, пиры и трекеры",
+	HelpFiles:       "выбрать файлы торрента и их приоритет",
+	HelpDelete:      "удалить торрент, можно вместе с файлами",
+	HelpSpeed:       "лимиты скорости и режим черепахи",
+	HelpSpeedSet:    "задать лимиты загрузки и отдачи, off — без ограничений",
+	HelpSchedule:    "расписания лимитов скорости",
+	HelpScheduleAdd: "ограничивать скорость каждый день в эти часы",
+	HelpUnschedule:  "удалить расписание",
+	HelpDisk:        "свободное место на дисках",
+
+	SearchItem:         "%s\n<b>Размер:%s</b>,Сиды:%s,%s\n/%s\t\t\t<a href=\"%s\">подробнее</a>\n\n",
+	ArticleDescription: "Размер: %s, сиды: %s, %s",
+	ArticleText:        "<b>%s</b>\nРазмер: %s, сиды: %s\n<a href=\"%s\">подробнее</a>",
+	JackettDescription: "Размер: %s, сиды: %d, %s",
+	JackettText:        "<b>%s</b>\nРазмер: %s, сиды: %d, %s",
+	JackettItem:        "Название: %s\nРазмер: %d\nСиды: %d\nСкачать: /download_%d",
+	TorrentFileItem:    "• %s — %s\n",
+	MoreFiles:          "…и еще %d\n",
+	InfoSummary:        "<b>%s</b>\n%.1f%%, %s\n↓ %s/s ↑ %s/s, осталось %s\nРазмер: %s, рейтинг %s\nПапка: %s\nДобавлен: %s\nЗавершен: %s\n",
+	InfoError:          "Ошибка: %s\n",
+	InfoFileItem:       "%.1f%% %s — %s\n",
+	InfoPeerItem:       "%s %s — ↓ %s/s ↑ %s/s, %.1f%%\n",
+	InfoTrackerItem:    "%s — сидов %d, личей %d\n",
+	InfoTrackerError:   "  ошибка: %s\n",
+	NoPeers:            "Нет подключенных пиров\n",
+	NoTrackers:         "Нет трекеров\n",
+	DownloadingItem:    "<b>%s</b>\n%.1f%%, %s, %s\n/info_%d %s /delete_%d\n\n",
+	FinishedItem:       "<b>%s</b>\n%s, рейтинг %s, %s\n/info_%d %s /delete_%d\n\n",
+	ScheduleItem:       "%d. %s — ↓ %s ↑ %s /unschedule_%d\n",
+
+	StatusStopped:      "остановлен",
+	StatusCheckWait:    "ждет проверки файлов",
+	StatusCheck:        "проверяет файлы",
+	StatusDownloadWait: "в очереди на загрузку",
+	StatusDownload:     "загружается",
+	StatusSeedWait:     "в очереди на раздачу",
+	StatusSeed:         "раздается",
+	StatusIsolated:     "не находит пиров",
+	StatusStalled:      "загрузка стоит",
+	StatusUnknown:      "неизвестно",
+	StatusCount:        "%s: %d",
+	ETAUnknown:         "неизвестно",
+	ProgressError:      "Ошибка: %s\n",
+	ProgressSpeed:      "↓ %s/s ↑ %s/s, осталось %s",
+	DownloadSpeed:      "↓ %s/s, осталось %s",
+}
+
+var english = map[Key]string{
+	ButtonEverywhere:       "Everywhere",
+	ButtonMovies:           "Movies",
+	ButtonSeries:           "Series",
+	ButtonAudiobooks:       "Audiobooks",
+	ButtonBooks:            "Books",
+	ButtonTorrentFile:      "Get torrent file",
+	ButtonToServer:         "To server",
+	ButtonDownloadOnServer: "Download on server",
+	ButtonMore:             "Next results",
+	ButtonOtherProviders:   "Search elsewhere",
+	ButtonSizeLimit:        "Limit size",
+	ButtonOpenRutracker:    "Open on RuTracker",
+	ButtonDownload:         "Download",
+	ButtonCancel:           "Cancel",
+	ButtonSendFiles:        "Send me the files",
+	ButtonSortName:         "By name",
+	ButtonSortProgress:     "By progress",
+	ButtonSortSize:         "By size",
+	ButtonSortAdded:        "By date",
+	ButtonPrevious:         "◀ Back",
+	ButtonNext:             "Next ▶",
+	ButtonStop:             "⏸ Stop",
+	ButtonStart:            "▶ Start",
+	ButtonStartNow:         "⏩ Start now",
+	ButtonVerify:           "Verify",
+	ButtonReannounce:       "Reannounce",
+	ButtonDeleteTorrent:    "Remove torrent only",
+	ButtonDeleteWithFiles:  "Remove torrent and files",
+	ButtonInfoSummary:      "Summary",
+	ButtonInfoFiles:        "Files",
+	ButtonInfoPeers:        "Peers",
+	ButtonInfoTrackers:     "Trackers",
+	ButtonFilesWanted:      "✅ Download",
+	ButtonPriorityHigh:     "⬆ High",
+	ButtonPriorityNormal:   "Normal",
+	ButtonPriorityLow:      "⬇ Low",
+	ButtonFilesAll:         "All",
+	ButtonFilesNone:        "None",
+	ButtonTurtleOn:         "🐢 Turtle mode on",
+	ButtonTurtleOff:        "Turtle mode off",
+	ButtonUnlimited:        "Remove limits",
+
+	WhatToDo:            "What should I do?",
+	WhereToSearch:       "Where to search?",
+	AskSizeLimit:        "Maximum size? For example: 700 MB or 10 GB. /cancel to cancel",
+	BadSizeLimit:        "Can't read the size, for example: 700 MB or 10 GB. /cancel to cancel",
+	ConversationTimeout: "No answer for too long, please start again",
+	Cancelled:           "Cancelled",
+	NothingToCancel:     "Nothing to cancel",
+	Saved:               "Saved: %s",
+	NothingSaved:        "Nothing saved",
+	Deleted:             "Deleted: %s",
+	DeletedWithFiles:    "Deleted with files: %s",
+	ConfirmDelete:       "Delete <b>%s</b>, %s?",
+	DownloadingMagnet:   "Downloading from magnet",
+	StartLoading:        "Start loading: %s",
+	Finished:            "%s finished",
+	QueueStats:          "Queued messages: %d in %d chats\nLongest queue: %d (chat %d)\nSent: %d, throttled: %d",
+	AiAdvice:            "<b>AI advice:</b>\n%s",
+	Scheduled:           "Scheduled",
+	ChooseLanguage:      "Choose language",
+	LanguageChanged:     "Language: English",
+	MagnetSummary:       "<b>%s</b>\nInfohash: <code>%s</code>\nTrackers: %s\n\nDownload to the server?",
+	TorrentSummary:      "<b>%s</b>\nSize: %s, files: %d\nPiece size: %s, private: %s\nInfohash: <code>%s</code>\nTrackers: %s\n\nBiggest files:\n%s\nDownload to the server?",
+	Unnamed:             "Unnamed",
+	Yes:                 "yes",
+	No:                  "no",
+	NoDownloading:       "Nothing is downloading",
+	NoFinished:          "No finished torrents",
+	ListPage:            "Page %d of %d",
+	ControlResult:       "<b>%s</b>: %s",
+	ControlAllResult:    "All torrents: %s",
+	FilesHeader:         "<b>%s</b>\nSelected %d of %d files, %s\n%s",
+	FilesHintWanted:     "Tap a file to download or skip it",
+	FilesHintPriority:   "Tap a file to set priority: %s",
+	NoTorrentFiles:      "Files of the torrent are not known yet",
+	SpeedStatus:         "Download: %s\nUpload: %s\nTurtle mode: %s, ↓ %s ↑ %s",
+	SpeedUnlimited:      "unlimited",
+	ScheduleAdded:       "Schedule added: %s",
+	ScheduleRemoved:     "Schedule removed: %s",
+	NoSchedules:         "No schedules. Add one: /schedule 18:00-23:00 2MB",
+	SchedulesHeader:     "Speed schedules, the first matching one is used:",
+	DiskHeader:          "Disk space:",
+	DiskItem:            "<b>%s</b> <code>%s</code>\n%s free of %s\n",
+	LowSpace:            "Warning: after downloading %s only %s of free space is left",
+
+	MenuExpired:     "This menu has expired, please repeat the request",
+	PrivateBot:      "Sorry, this is a private bot. Ask the owner to add your id %d",
+	PrivateBotShort: "Sorry, this is a private bot",
+	SeePrivateChat:  "Done, see private chat with the bot",
+
+	ErrorWithStack:          "Error: %v, Stacktrace url: %s",
+	InvalidTorrentID:        "Invalid torrent ID %s",
+	NoMagnet:                "Try search again, no magnet URI found for ID: %s",
+	AddTorrentError:         "Error adding torrent: %v",
+	DownloadError:           "Cannot download: %v",
+	DeleteError:             "Error deleting torrent: %v",
+	DeleteFailed:            "Failed to delete torrent",
+	WaitStartError:          "Waited for torrent to start loading, but error: %v",
+	WaitFinishError:         "Waited for torrent to finish, but error: %v",
+	StoppedWatching:         "Stopped watching torrent: %v",
+	SearchError:             "Error searching: %v",
+	NoResults:               "No results found",
+	NoResultsWithSize:       "No results with this size",
+	TransmissionError:       "Error connecting to transmission: %v",
+	TransmissionUnavailable: "Could not connect to transmission",
+	UnknownLanguage:         "Unknown language, available: %s",
+	InvalidMagnet:           "Can't read magnet link: %v",
+	NotTorrentFile:          "This is not a .torrent file, please send a torrent file or a magnet link",
+	InvalidTorrentFile:      "Can't read .torrent file: %v",
+	UploadError:             "Can't send the files: %v",
+	TooBigToSend:            "The download is too big to send it to telegram",
+	NoFilesToSend:           "No files to send",
+	ControlError:            "Action failed: %v",
+	AdminOnly:               "Only admins can do it",
+	BadSpeed:                "Can't read speed: %v. Example: /speed 2MB 500KB",
+	BadSchedule:             "Can't read schedule: %v. Example: /schedule 18:00-23:00 2MB 500KB",
+	NoSuchSchedule:          "No schedule %s",
+	DiskError:               "<b>%s</b> <code>%s</code>\nerror: %s\n",
+	NotEnoughSpace:          "Not enough space: %s is needed, %s is free",
+
+	Welcome:         "Hi! I search torrents on RuTracker and Jackett and download them to the server.",
+	HelpHeader:      "Send a movie or book title to search, or send a .torrent file.",
+	HelpHelp:        "list of commands",
+	HelpDownload:    "download Jackett search result",
+	HelpSaved:       "saved searches",
+	HelpDownloading: "torrents being downloaded",
+	HelpFinished:    "downloaded torrents",
+	HelpVersion:     "bot version",
+	HelpStats:       "outgoing message queues",
+	HelpLang:        "choose language",
+	HelpTopic:       "download RuTracker topic",
+	HelpCancel:      "cancel current question",
+	HelpMagnet:      "download magnet link",
+	HelpTopicUrl:    "download topic by link",
+	HelpControl:     "stop, start, start without queue, verify or reannounce torrent",
+	HelpControlAll:  "the same for all torrents",
+	HelpInfo:        "torrent details: files, peers and trackers",
+	HelpFiles:       "choose files of torrent and their priority",
+	HelpDelete:      "remove torrent, optionally with its files",
+	HelpSpeed:       "speed limits and turtle mode",
+	HelpSpeedSet:    "set download and upload limits, off removes a limit",
+	HelpSchedule:    "speed limit schedules",
+	HelpScheduleAdd: "limit speed every day in these hours",
+	HelpUnschedule:  "remove schedule",
+	HelpDisk:        "free disk space",
+
+	SearchItem:         "%s\n<b>Size:%s</b>,Seeds:%s,%s\n/%s\t\t\t<a href=\"%s\">details</a>\n\n",
+	ArticleDescription: "Size: %s, Seeds: %s, %s",
+	ArticleText:        "<b>%s</b>\nSize: %s, Seeds: %s\n<a href=\"%s\">details</a>",
+	JackettDescription: "Size: %s, Seeds: %d, %s",
+	JackettText:        "<b>%s</b>\nSize: %s, Seeds: %d, %s",
+	JackettItem:        "Title: %s\nSize: %d\nSeeders: %d\nDownload: /download_%d",
+	TorrentFileItem:    "• %s — %s\n",
+	MoreFiles:          "…and %d more\n",
+	InfoSummary:        "<b>%s</b>\n%.1f%%, %s\n↓ %s/s ↑ %s/s, ETA %s\nSize: %s, ratio %s\nFolder: %s\nAdded: %s\nDone: %s\n",
+	InfoError:          "Error: %s\n",
+	InfoFileItem:       "%.1f%% %s — %s\n",
+	InfoPeerItem:       "%s %s — ↓ %s/s ↑ %s/s, %.1f%%\n",
+	InfoTrackerItem:    "%s — seeders %d, leechers %d\n",
+	InfoTrackerError:   "  error: %s\n",
+	NoPeers:            "No connected peers\n",
+	NoTrackers:         "No trackers\n",
+	DownloadingItem:    "<b>%s</b>\n%.1f%%, %s, %s\n/info_%d %s /delete_%d\n\n",
+	FinishedItem:       "<b>%s</b>\n%s, ratio %s, %s\n/info_%d %s /delete_%d\n\n",
+	ScheduleItem:       "%d. %s — ↓ %s ↑ %s /unschedule_%d\n",
+
+	StatusStopped:      "stopped",
+	StatusCheckWait:    "waiting to check files",
+	StatusCheck:        "checking files",
+	StatusDownloadWait: "waiting to download",
+	StatusDownload:     "downloading",
+	StatusSeedWait:     "waiting to seed",
+	StatusSeed:         "seeding",
+	StatusIsolated:     "can't find peers",
+	StatusStalled:      "stalled",
+	StatusUnknown:      "unknown",
+	StatusCount:        "%s: %d",
+	ETAUnknown:         "unknown",
+	ProgressError:      "Error: %s\n",
+	ProgressSpeed:      "↓ %s/s ↑ %s/s, ETA %s",
+	DownloadSpeed:      "↓ %s/s, ETA %s",
+}
@@ -3,14 +3,21 @@ package main
 import (
 	"context"
 	"fmt"
+	"html"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
-	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 	"unicode"
 
 	"github.com/telegram-command-reader/bot"
 	"github.com/telegram-command-reader/config"
+	"github.com/telegram-command-reader/i18n"
 	"github.com/telegram-command-reader/operations"
 	"github.com/telegram-command-reader/operations/ai"
 	"github.com/telegram-command-reader/operations/jackett"
@@ -20,29 +27,94 @@ import (
 )
 
 var (
-	jacketClient              *jackett.Jackett       // this is lib to search all torrent providers
-	lastJackettRequestResults map[int]jackett.Result = make(map[int]jackett.Result)
+	jacketClient              *jackett.Jackett // this is lib to search all torrent providers
+	lastJackettRequestResults = &jackettResults{results: make(map[int64][]jackett.Result)}
+	finishedFolderPath        string // downloaded content, files are sent to the chat from here
+	torrentFolderPath         string // torrent files are saved here for transmission
+	minFreeSpace              int64  // bytes which should stay free after download
 )
 
-// safely call function without panic
-func safeCall(f func(), onError func(string)) {
-	defer func() {
-		if r := recover(); r != nil {
-			// print recovered stack trace
-			fmt.Println("stacktrace from panic: \n" + string(debug.Stack()))
-			fmt.Println("Recovered in f", r)
-			stackTraceUrl, sterr := operations.SendStringToPastebin(string(debug.Stack()))
-			if sterr != nil {
-				fmt.Println("Send stacktrace error ", sterr)
-			} else {
-				result := fmt.Sprintf("Error: %s, Stacktrace url: %s", r, stackTraceUrl)
-				onError(result)
-			}
+// results of the last jackett search in each chat, /download_<id> takes result with index id,
+// handlers run concurrently so access is locked
+type jackettResults struct {
+	mutex   sync.Mutex
+	results map[int64][]jackett.Result
+}
+
+func (last *jackettResults) set(chatID int64, results []jackett.Result) {
+	last.mutex.Lock()
+	defer last.mutex.Unlock()
+	if len(results) == 0 {
+		delete(last.results, chatID)
+		return
+	}
+	last.results[chatID] = results
+}
+
+func (last *jackettResults) get(chatID int64, id int) jackett.Result {
+	last.mutex.Lock()
+	defer last.mutex.Unlock()
+	results := last.results[chatID]
+	if id < 0 || id >= len(results) {
+		return jackett.Result{}
+	}
+	return results[id]
+}
+
+// report panic from handler to the chat, stacktrace is uploaded to pastebin
+func reportPanic(outputChannel chan bot.OutMessage) func(*bot.Info, interface{}, []byte) {
+	return func(message *bot.Info, recovered interface{}, stack []byte) {
+		stackTraceUrl, sterr := operations.SendStringToPastebin(string(stack))
+		if sterr != nil {
+			fmt.Println("Send stacktrace error ", sterr)
+			return
 		}
-	}()
-	f()
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ErrorWithStack, recovered, stackTraceUrl)}
+	}
 }
 
+// convert role names from config to bot roles
+func parseRoles(list map[int64]string) (map[int64]bot.Role, error) {
+	result := make(map[int64]bot.Role)
+	for id, name := range list {
+		role, err := bot.ParseRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("id %d: %w", id, err)
+		}
+		result[id] = role
+	}
+	return result, nil
+}
+
+// names of keyboard actions, they are saved with sent messages so don't rename them
+const (
+	actionTopicDownload   = "topic_download"
+	actionJackettDownload = "jackett_download"
+	actionSearch          = "search"
+	actionSearchResults   = "search_results"
+	actionLanguage        = "language"
+	actionMagnetDownload  = "magnet_download"
+	actionTorrentFile     = "torrent_file"
+	actionSendFiles       = "send_files"
+	actionTorrentList     = "torrent_list"
+	actionTorrentControl  = "torrent_control"
+	actionDeleteTorrent   = "delete_torrent"
+	actionTorrentInfo     = "torrent_info"
+	actionTorrentFiles    = "torrent_files"
+	actionSpeed           = "speed"
+)
+
+// views of torrent list in buttons
+const (
+	viewDownloading = "downloading"
+	viewFinished    = "finished"
+)
+
+// states of conversations waiting for text answer
+const (
+	stateSizeLimit = "size_limit"
+)
+
 func main() {
 	version := "Telegram downloader version 17"
 	fmt.Println(version)
@@ -62,13 +134,59 @@ func main() {
 	rutracker.USER_NAME = envConfig.RuTrackerUserName
 	rutracker.USER_PASSWORD = envConfig.RuTrackerPassword
 	bot.API_TOKEN = envConfig.TelegramBotToken
+	bot.API_URL = envConfig.TelegramApiUrl
+	finishedFolderPath = envConfig.FinishedFolder
+	torrentFolderPath = envConfig.TorrentFileFolder
+	minFreeSpace = int64(envConfig.MinFreeSpaceGB) << 30
+	operations.AUDIT_LOG = config.CreateFilePath(envConfig.DataFolder, "audit.log")
 	storage.API_KEY = envConfig.KVDBToken
 	ai.API_KEY = envConfig.GeminiApiKey
 	transmission.RPC_URI = envConfig.TransmissionUri
 	transmission.RPC_PORT_FROM = envConfig.TransmissionPortFrom
 	transmission.RPC_PORT_TO = envConfig.TransmissionPortTo
 
-	outputChannel := make(chan bot.OutMessage)
+	users, err := parseRoles(envConfig.AllowedUsers)
+	if err != nil {
+		fmt.Println("Load config error ", err)
+		return
+	}
+	chats, err := parseRoles(envConfig.AllowedChats)
+	if err != nil {
+		fmt.Println("Load config error ", err)
+		return
+	}
+	if len(users) == 0 && len(chats) == 0 {
+		fmt.Println("Warning: ALLOWED_USERS is empty, nobody can use the bot")
+	}
+	bot.SetAccessList(users, chats)
+
+	if err := bot.LoadCallbacks(config.CreateFilePath(envConfig.DataFolder, "callbacks.json")); err != nil {
+		fmt.Println("Load callbacks error ", err)
+	}
+
+	if err := i18n.LoadUserLanguages(config.CreateFilePath(envConfig.DataFolder, "languages.json")); err != nil {
+		fmt.Println("Load languages error ", err)
+	}
+
+	if err := transmission.LoadSchedules(config.CreateFilePath(envConfig.DataFolder, "schedules.json")); err != nil {
+		fmt.Println("Load schedules error ", err)
+	}
+
+	// ctx is done on signal, then updates are not received anymore,
+	// work started by messages is cancelled later with cancelWork
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+	bot.SetContext(workCtx)
+
+	outputChannel := make(chan bot.OutMessage, 100)
+
+	// every handler runs in own goroutine wrapped with these middlewares
+	bot.Use(bot.RecoverMiddleware(reportPanic(outputChannel)), bot.LoggingMiddleware, bot.TimingMiddleware, bot.AccessMiddleware(func(message *bot.Info) {
+		reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.PrivateBot, message.UserID())}
+		outputChannel <- reply
+	}))
 
 	bot.AddHandler(bot.NewCommandMatcher("/search_([A-Za-z0-9+/]+={0,2})"), func(message *bot.Info) {
 		re := regexp.MustCompile("^/search_([A-Za-z0-9+/]+={0,2})$")
@@ -77,78 +195,39 @@ func main() {
 			movie_name := bot.DecodeStringFromCommand(match1[1])
 			searchTorrent(message, movie_name, outputChannel)
 		}
-	})
+	}).Named("search").Requires(bot.RoleGuest)
 
 	bot.AddHandler(bot.NewCommandMatcher("/download_([0-9]+)"), func(message *bot.Info) {
 		idStr := message.Text[10:]
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			reply := bot.OutMessage{OriginalMessage: message, Text: "Invalid torrent ID: " + err.Error()}
+			reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidTorrentID, idStr)}
 			outputChannel <- reply
 			return
 		}
 
-		go safeCall(func() {
-			magnetUri := lastJackettRequestResults[id].MagnetUri
-			linkUri := lastJackettRequestResults[id].Link
-			if magnetUri == "" && linkUri == "" {
-				reply := bot.OutMessage{OriginalMessage: message, Text: "Try search again, no magnet URI found for ID: " + idStr}
-				outputChannel <- reply
-				return
-			}
+		result := lastJackettRequestResults.get(message.ChatID(), id)
+		magnetUri := result.MagnetUri
+		linkUri := result.Link
+		if magnetUri == "" && linkUri == "" {
+			reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NoMagnet, idStr)}
+			outputChannel <- reply
+			return
+		}
 
-			if magnetUri != "" {
-				result, err := transmission.AddTorrent(magnetUri)
-				if err != nil {
-					reply := bot.OutMessage{OriginalMessage: message, Text: "Error adding torrent: " + err.Error()}
-					outputChannel <- reply
-					return
-				}
-				if result {
-					outputChannel <- bot.OutMessage{OriginalMessage: message, Text: "Downloading from magnet"}
-					return
-				}
+		if magnetUri != "" {
+			if checkFreeSpace(message, int64(result.Size), outputChannel) {
+				addMagnet(message, magnetUri, outputChannel)
 			}
+			return
+		}
 
-			if linkUri != "" {
-				reply := bot.OutMessage{OriginalMessage: message, Text: "Что делаем?", UseInlineKeyboard: true, InlineKeyboard: bot.DownloadActionKeyboard, ReplyCallback: func(data string) {
-					fileTitle := strings.Map(func(r rune) rune {
-						if unicode.IsLetter(r) || unicode.IsNumber(r) {
-							return r
-						}
-						return '_'
-					}, lastJackettRequestResults[id].Title)
-					if data == bot.DownloadActionFile {
-						operations.DownloadJackettTorrentByUriToStream(linkUri, func(result operations.OperationResult) {
-							if result.Err != nil {
-								fmt.Println(result.Text)
-								reply := bot.OutMessage{OriginalMessage: message, Text: result.Err.Error()}
-								outputChannel <- reply
-							} else {
-								fmt.Println("saved torrent file to stream")
-								reply := bot.OutMessage{OriginalMessage: message, Text: fileTitle + ".torrent", FileStream: result.FileStream}
-								outputChannel <- reply
-							}
-						})
-					} else if data == bot.DownloadActionServer {
-						destinationPath := config.CreateFilePath(envConfig.TorrentFileFolder, fileTitle+".torrent")
-						operations.DownloadJackettTorrentByUri(linkUri, destinationPath, func(result operations.OperationResult) {
-							if result.Err != nil {
-								fmt.Println(result.Text)
-							} else {
-								fmt.Println("saved torrent file to ", destinationPath)
-								go monitorTorrentUpdates(activeFolder, message, outputChannel, finishedFolder)
-							}
-						})
-					}
-				}}
-				outputChannel <- reply
-			}
-		}, func(result string) {
-			reply := bot.OutMessage{OriginalMessage: message, Text: result}
+		if linkUri != "" {
+			params := map[string]string{"link": linkUri, "title": result.Title}
+			reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.WhatToDo), UseInlineKeyboard: true, InlineKeyboard: bot.DownloadActionKeyboard(message.Language(), bot.NewAction(actionJackettDownload, params))}
 			outputChannel <- reply
-		})
-	})
+		}
+	}).Named("download").Describe("/download_<id>", i18n.HelpDownload)
 
 	bot.AddHandler(bot.NewCommandMatcher("/delete_([A-Za-z0-9+/]+={0,2})"), func(message *bot.Info) {
 		re := regexp.MustCompile("^/delete_([A-Za-z0-9+/]+={0,2})$")
@@ -156,248 +235,579 @@ func main() {
 		if len(match1) > 0 {
 			id, err := strconv.ParseInt(match1[1], 10, 64)
 			if err != nil {
-				reply := bot.OutMessage{OriginalMessage: message, Text: "Invalid torrent ID"}
+				reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidTorrentID, match1[1])}
 				outputChannel <- reply
 				return
 			}
-			ok, err := transmission.RemoveTorrent(id)
+			torrent, err := transmission.GetTorrent(message.Context(), id)
 			if err != nil {
-				reply := bot.OutMessage{OriginalMessage: message, Text: "Error deleting torrent: " + err.Error()}
+				reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DeleteError, err)}
 				outputChannel <- reply
 				return
 			}
-			if ok {
-				reply := bot.OutMessage{OriginalMessage: message, Text: "Deleted:" + bot.DecodeStringFromCommand(match1[1])}
-				outputChannel <- reply
-			} else {
-				reply := bot.OutMessage{OriginalMessage: message, Text: "Failed to delete torrent"}
-				outputChannel <- reply
+			name := ""
+			if torrent.Name != nil {
+				name = *torrent.Name
 			}
+			text := message.T(i18n.ConfirmDelete, html.EscapeString(name), transmission.SizeString(torrent))
+			keyboard := bot.DeleteKeyboard(message.Language(), bot.NewAction(actionDeleteTorrent, map[string]string{"id": match1[1]}))
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text, Html: true, UseInlineKeyboard: true, InlineKeyboard: keyboard}
 		}
-	})
+	}).Named("delete").Describe("/delete_<id>", i18n.HelpDelete).Requires(bot.RoleAdmin)
 
 	bot.AddHandler(bot.NewCommandMatcher("/save_([A-Za-z0-9+/]+={0,2})"), func(message *bot.Info) {
 		// read all files and send them to output channel
-		go safeCall(func() {
-			re := regexp.MustCompile("^/save_([A-Za-z0-9+/]+={0,2})$")
-			match1 := re.FindStringSubmatch(message.Text)
-			if len(match1) > 0 {
-				movie_name := bot.DecodeStringFromCommand(match1[1])
-				if storage.SetKeyValue(bot.EncodeString(movie_name), movie_name) {
-					reply := bot.OutMessage{OriginalMessage: message, Text: "Saved:" + movie_name}
-					outputChannel <- reply
-				}
+		re := regexp.MustCompile("^/save_([A-Za-z0-9+/]+={0,2})$")
+		match1 := re.FindStringSubmatch(message.Text)
+		if len(match1) > 0 {
+			movie_name := bot.DecodeStringFromCommand(match1[1])
+			if storage.SetKeyValue(bot.EncodeString(movie_name), movie_name) {
+				reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.Saved, movie_name)}
+				outputChannel <- reply
 			}
-		}, func(result string) {
-			reply := bot.OutMessage{OriginalMessage: message, Text: result}
-			outputChannel <- reply
-		})
-	})
+		}
+	}).Named("save")
 
 	bot.AddHandler(bot.NewCommandMatcher("/saved"), func(message *bot.Info) {
 		// read all files and send them to output channel
-		go safeCall(func() {
-			list := storage.GetAllKeys()
+		list := storage.GetAllKeys()
 
-			// convert list to string, if list is empty then send message "No files"
-			if len(list) == 0 {
-				outputChannel <- bot.OutMessage{OriginalMessage: message, Text: "Nothing saved"}
-			} else {
-				result := ""
-				for _, item := range list {
-					result += (bot.DecodeStringFromCommand(item) + "\n")
-					result += ("/search_" + item + "\n")
-					result += ("/delete_" + item + "\n\n")
-				}
-
-				reply := bot.OutMessage{OriginalMessage: message, Text: result}
-				outputChannel <- reply
+		// convert list to string, if list is empty then send message "No files"
+		if len(list) == 0 {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NothingSaved)}
+		} else {
+			result := ""
+			for _, item := range list {
+				result += (bot.DecodeStringFromCommand(item) + "\n")
+				result += ("/search_" + item + "\n")
+				result += ("/delete_" + item + "\n\n")
 			}
-		}, func(result string) {
+
 			reply := bot.OutMessage{OriginalMessage: message, Text: result}
 			outputChannel <- reply
-		})
-	})
+		}
+	}).Named("saved").Describe("/saved", i18n.HelpSaved)
 
 	bot.AddHandler(bot.NewCommandMatcher("/downloading"), func(message *bot.Info) {
-		// read all files and send them to output channel
-		go safeCall(func() {
-			showTorrentList(message, outputChannel)
-		}, func(result string) {
-			reply := bot.OutMessage{OriginalMessage: message, Text: result}
-			outputChannel <- reply
-		})
-	})
+		showTorrentList(message, viewDownloading, transmission.SortProgress, 0, 0, outputChannel)
+	}).Named("downloading").Describe("/downloading", i18n.HelpDownloading)
 
 	bot.AddHandler(bot.NewCommandMatcher("/finished"), func(message *bot.Info) {
-		// read all files and send them to output channel
-		go safeCall(func() {
-			showTorrentList(message, outputChannel)
-		}, func(result string) {
-			reply := bot.OutMessage{OriginalMessage: message, Text: result}
-			outputChannel <- reply
-		})
-	})
+		showTorrentList(message, viewFinished, transmission.SortAdded, 0, 0, outputChannel)
+	}).Named("finished").Describe("/finished", i18n.HelpFinished)
+
+	bot.AddHandler(bot.NewCommandMatcher("/(stop|start|startnow|verify|reannounce)_([0-9]+)"), func(message *bot.Info) {
+		action, id, _ := strings.Cut(message.Text[1:], "_")
+		controlTorrents(message, action, id, 0, outputChannel)
+	}).Named("torrent_control").Describe("/stop_<id>, /start_<id>, /startnow_<id>, /verify_<id>, /reannounce_<id>", i18n.HelpControl)
+
+	bot.AddHandler(bot.NewCommandMatcher("/(stop|start|startnow|verify|reannounce)_all"), func(message *bot.Info) {
+		action, _, _ := strings.Cut(message.Text[1:], "_")
+		controlTorrents(message, action, "", 0, outputChannel)
+	}).Named("torrent_control_all").Describe("/stop_all, /start_all, /startnow_all, /verify_all, /reannounce_all", i18n.HelpControlAll).Requires(bot.RoleAdmin)
+
+	bot.AddHandler(bot.NewCommandMatcher("/info_([0-9]+)"), func(message *bot.Info) {
+		showTorrentInfo(message, message.Text[len("/info_"):], bot.InfoSummary, 0, outputChannel)
+	}).Named("info").Describe("/info_<id>", i18n.HelpInfo)
+
+	bot.AddHandler(bot.NewCommandMatcher("/files_([0-9]+)"), func(message *bot.Info) {
+		id, _ := strconv.ParseInt(message.Text[len("/files_"):], 10, 64)
+		showTorrentFiles(message, id, 0, bot.FilesModeWanted, 0, outputChannel)
+	}).Named("files").Describe("/files_<id>", i18n.HelpFiles)
+
+	bot.AddHandler(bot.NewCommandMatcher("/speed"), func(message *bot.Info) {
+		showSpeed(message, 0, outputChannel)
+	}).Named("speed").Describe("/speed", i18n.HelpSpeed)
+
+	bot.AddHandler(bot.NewCommandMatcher(`/speed \S+( \S+)?`), func(message *bot.Info) {
+		setSpeed(message, strings.Fields(message.Text)[1:], outputChannel)
+	}).Named("speed_set").Describe("/speed <down> [up]", i18n.HelpSpeedSet).Requires(bot.RoleAdmin)
+
+	bot.AddHandler(bot.NewCommandMatcher("/schedule"), func(message *bot.Info) {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: formatSchedules(transmission.Schedules(), message.Language())}
+	}).Named("schedule").Describe("/schedule", i18n.HelpSchedule)
+
+	bot.AddHandler(bot.NewCommandMatcher("/schedule .+"), func(message *bot.Info) {
+		addSchedule(message, message.Text[len("/schedule "):], outputChannel)
+	}).Named("schedule_add").Describe("/schedule 18:00-23:00 <down> [up]", i18n.HelpScheduleAdd).Requires(bot.RoleAdmin)
+
+	bot.AddHandler(bot.NewCommandMatcher("/unschedule_([0-9]+)"), func(message *bot.Info) {
+		removeSchedule(message, message.Text[len("/unschedule_"):], outputChannel)
+	}).Named("unschedule").Describe("/unschedule_<n>", i18n.HelpUnschedule).Requires(bot.RoleAdmin)
+
+	bot.AddHandler(bot.NewCommandMatcher("/disk"), func(message *bot.Info) {
+		showDiskSpace(message, outputChannel)
+	}).Named("disk").Describe("/disk", i18n.HelpDisk)
+
+	bot.AddHandler(bot.NewCommandMatcher("/help"), func(message *bot.Info) {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.HelpHeader) + "\n\n" + bot.HelpText(message)}
+	}).Named("help").Describe("/help", i18n.HelpHelp).Requires(bot.RoleGuest)
+
+	bot.AddHandler(bot.NewCommandMatcher("/start"), func(message *bot.Info) {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.Welcome) + "\n\n" + bot.HelpText(message)}
+	}).Named("start").Requires(bot.RoleGuest)
 
 	bot.AddHandler(bot.NewCommandMatcher("/version"), func(message *bot.Info) {
 		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: version}
-	})
+	}).Named("version").Describe("/version", i18n.HelpVersion).Requires(bot.RoleGuest)
+
+	bot.AddHandler(bot.NewCommandMatcher("/stats"), func(message *bot.Info) {
+		stats := bot.OutgoingStats()
+		text := message.T(i18n.QueueStats, stats.Queued, stats.Chats, stats.Longest, stats.LongestChat, stats.Sent, stats.Throttled)
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text}
+	}).Named("stats").Describe("/stats", i18n.HelpStats).Requires(bot.RoleAdmin)
+
+	bot.AddHandler(bot.NewCommandMatcher("/lang( [a-z]+)?"), func(message *bot.Info) {
+		fields := strings.Fields(message.Text)
+		if len(fields) == 1 {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ChooseLanguage), UseInlineKeyboard: true, InlineKeyboard: bot.LanguageKeyboard(bot.NewAction(actionLanguage, nil))}
+			return
+		}
+		setLanguage(message, fields[1], outputChannel)
+	}).Named("lang").Describe("/lang", i18n.HelpLang).Requires(bot.RoleGuest)
 
 	bot.AddHandler(bot.NewCommandMatcher("/[0-9]+"), func(originalMessage *bot.Info) {
 		topicId := originalMessage.Text[1:]
 		fmt.Println("Command /[0-9]+", topicId)
-		bot.SendTypingStatus(originalMessage)
-		destinationPath := config.CreateFilePath(envConfig.TorrentFileFolder, topicId+".torrent")
-		go safeCall(func() {
-			reply := bot.OutMessage{OriginalMessage: originalMessage, Text: "Что делаем?", UseInlineKeyboard: true, InlineKeyboard: bot.DownloadActionKeyboard, ReplyCallback: func(data string) {
-				if data == bot.DownloadActionFile {
-					operations.DownloadTorrentByPostIdToStream(topicId, func(result operations.OperationResult) {
-						if result.Err != nil {
-							fmt.Println(result.Text)
-							reply := bot.OutMessage{OriginalMessage: originalMessage, Text: result.Err.Error()}
-							outputChannel <- reply
-						} else {
-							fmt.Println("saved torrent file to stream")
-							reply := bot.OutMessage{OriginalMessage: originalMessage, Text: topicId + ".torrent", FileStream: result.FileStream}
-							outputChannel <- reply
-						}
-					})
-				} else if data == bot.DownloadActionServer {
-					operations.DownloadTorrentByPostId(topicId, destinationPath, func(result operations.OperationResult) {
-						if result.Err != nil {
-							fmt.Println(result.Text)
-						} else {
-							fmt.Println("saved torrent file to ", destinationPath)
-							go monitorTorrentUpdates(activeFolder, originalMessage, outputChannel, finishedFolder)
-						}
-					})
-				}
-			}}
-			outputChannel <- reply
-		}, func(result string) {
-			reply := bot.OutMessage{OriginalMessage: originalMessage, Text: result}
-			outputChannel <- reply
-		})
-	})
+		showTopicActions(originalMessage, topicId, outputChannel)
+	}).Named("topic").Describe("/<topic id>", i18n.HelpTopic)
+
+	bot.AddHandler(bot.NewTextMatcher(`\s*https?://(www\.)?rutracker\.(org|net|nl)/forum/viewtopic\.php\?\S*\s*`), func(message *bot.Info) {
+		topicId, ok := rutracker.ParseTopicUrl(message.Text)
+		if !ok {
+			searchTorrent(message, message.Text, outputChannel)
+			return
+		}
+		showTopicActions(message, topicId, outputChannel)
+	}).Named("topic_url").Describe("https://rutracker.org/forum/viewtopic.php?t=<id>", i18n.HelpTopicUrl)
+
+	bot.AddHandler(bot.NewTextMatcher(`\s*magnet:\?\S+\s*`), func(message *bot.Info) {
+		uri := strings.TrimSpace(message.Text)
+		magnet, err := transmission.ParseMagnet(uri)
+		if err != nil {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidMagnet, err)}
+			return
+		}
+
+		name := magnet.Name
+		if name == "" {
+			name = message.T(i18n.Unnamed)
+		}
+		trackers := formatTrackers(len(magnet.Trackers), magnet.TrackerHosts())
+		text := message.T(i18n.MagnetSummary, html.EscapeString(name), magnet.InfoHash, html.EscapeString(trackers))
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text, Html: true, UseInlineKeyboard: true, InlineKeyboard: bot.ConfirmKeyboard(message.Language(), bot.NewAction(actionMagnetDownload, map[string]string{"magnet": uri}))}
+	}).Named("magnet").Describe("magnet:?xt=urn:btih:...", i18n.HelpMagnet)
+
+	bot.AddHandler(bot.NewConversationMatcher(), bot.ContinueConversation).Named("conversation").WithPriority(bot.PriorityHigh).Requires(bot.RoleGuest)
+
+	bot.AddHandler(bot.NewCommandMatcher("/cancel"), func(message *bot.Info) {
+		if bot.CancelConversation(message) {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.Cancelled)}
+		} else {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NothingToCancel)}
+		}
+	}).Named("cancel").Describe("/cancel", i18n.HelpCancel).Requires(bot.RoleGuest)
 
 	bot.AddHandler(bot.NewTextMatcher(".*"), func(message *bot.Info) {
 		searchTorrent(message, message.Text, outputChannel)
-	})
+	}).Named("text_search").WithPriority(bot.PriorityFallback).Requires(bot.RoleGuest)
 
 	bot.AddHandler(bot.NewFileNameMatcher(), func(message *bot.Info) {
-		destinationPath := config.CreateFilePath(envConfig.TorrentFileFolder, message.FileName)
+		if !strings.EqualFold(filepath.Ext(message.FileName), ".torrent") {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NotTorrentFile)}
+			return
+		}
 		bot.SendTypingStatus(message)
-		go safeCall(func() {
-			operations.Download(message.FileUrl, destinationPath, func(result operations.OperationResult) {
+		torrent, err := operations.InspectTorrent(message.Context(), message.FileUrl)
+		if err != nil {
+			fmt.Println("inspect torrent file error ", err)
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidTorrentFile, err)}
+			return
+		}
+		name := torrent.Name
+		if name == "" {
+			name = torrent.InfoHash
+		}
+		action := bot.NewAction(actionTorrentFile, map[string]string{"file": message.FileID, "name": name, "size": strconv.FormatInt(torrent.TotalSize, 10)})
+		text := formatTorrentSummary(torrent, message.Language())
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text, Html: true, UseInlineKeyboard: true, InlineKeyboard: bot.ConfirmKeyboard(message.Language(), action)}
+	}).Named("torrent_file")
+
+	bot.RegisterAction(actionJackettDownload, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		linkUri := params["link"]
+		fileTitle := safeFileName(params["title"])
+		if message.Text == bot.DownloadActionFile {
+			operations.DownloadJackettTorrentByUriToStream(message.Context(), linkUri, func(result operations.OperationResult) {
 				if result.Err != nil {
 					fmt.Println(result.Text)
-					reply := bot.OutMessage{OriginalMessage: message, Text: result.Text}
+					reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DownloadError, result.Err)}
 					outputChannel <- reply
 				} else {
-					fmt.Println("saved torrent file to ", destinationPath)
-					reply := bot.OutMessage{OriginalMessage: message, Text: result.Text}
+					fmt.Println("saved torrent file to stream")
+					reply := bot.OutMessage{OriginalMessage: message, Text: fileTitle + ".torrent", FileStream: result.FileStream}
 					outputChannel <- reply
 				}
 			})
-		}, func(s string) {
-			reply := bot.OutMessage{OriginalMessage: message, Text: s}
-			outputChannel <- reply
+		} else if message.Text == bot.DownloadActionServer {
+			destinationPath := config.CreateFilePath(envConfig.TorrentFileFolder, fileTitle+".torrent")
+			operations.DownloadJackettTorrentByUriToStream(message.Context(), linkUri, func(result operations.OperationResult) {
+				if result.Err != nil {
+					fmt.Println(result.Text)
+				} else if saveTorrentIfFits(message, result.FileStream, destinationPath, outputChannel) {
+					watchers.start(func() {
+						monitorTorrentUpdates(activeFolder, message, outputChannel, finishedFolder)
+					})
+				}
+			})
+		}
+	}, "link", "title")
+
+	bot.RegisterAction(actionTopicDownload, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		topicId := params["topic"]
+		if message.Text == bot.DownloadActionFile {
+			operations.DownloadTorrentByPostIdToStream(message.Context(), topicId, func(result operations.OperationResult) {
+				if result.Err != nil {
+					fmt.Println(result.Text)
+					reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DownloadError, result.Err)}
+					outputChannel <- reply
+				} else {
+					fmt.Println("saved torrent file to stream")
+					reply := bot.OutMessage{OriginalMessage: message, Text: topicId + ".torrent", FileStream: result.FileStream}
+					outputChannel <- reply
+				}
+			})
+		} else if message.Text == bot.DownloadActionServer {
+			destinationPath := config.CreateFilePath(envConfig.TorrentFileFolder, topicId+".torrent")
+			operations.DownloadTorrentByPostIdToStream(message.Context(), topicId, func(result operations.OperationResult) {
+				if result.Err != nil {
+					fmt.Println(result.Text)
+				} else if saveTorrentIfFits(message, result.FileStream, destinationPath, outputChannel) {
+					watchers.start(func() {
+						monitorTorrentUpdates(activeFolder, message, outputChannel, finishedFolder)
+					})
+				}
+			})
+		}
+	}, "topic")
+
+	bot.RegisterAction(actionMagnetDownload, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		// remove buttons, so the magnet is not added twice
+		outputChannel <- bot.OutMessage{OriginalMessage: message, EditMessageID: message.MessageID()}
+		if message.Text == bot.ConfirmYes {
+			addMagnet(message, params["magnet"], outputChannel)
+		} else {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.Cancelled)}
+		}
+	}, "magnet")
+
+	bot.RegisterAction(actionTorrentFile, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		// remove buttons, so the file is not scheduled twice
+		outputChannel <- bot.OutMessage{OriginalMessage: message, EditMessageID: message.MessageID()}
+		if message.Text != bot.ConfirmYes {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.Cancelled)}
+			return
+		}
+		size, _ := strconv.ParseInt(params["size"], 10, 64)
+		if !checkFreeSpace(message, size, outputChannel) {
+			return
+		}
+		fileUrl, err := bot.FileURL(params["file"])
+		if err != nil {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DownloadError, err)}
+			return
+		}
+		// name of uploaded file is not trusted, the file is named after the torrent
+		destinationPath := config.CreateFilePath(envConfig.TorrentFileFolder, safeFileName(params["name"])+".torrent")
+		operations.Download(message.Context(), fileUrl, destinationPath, func(result operations.OperationResult) {
+			if result.Err != nil {
+				fmt.Println(result.Text)
+				outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DownloadError, result.Err)}
+			} else {
+				fmt.Println("saved torrent file to ", destinationPath)
+				outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.Scheduled)}
+				// progress and file selection are shown like for other downloads
+				watchers.start(func() {
+					monitorTorrentUpdates(activeFolder, message, outputChannel, finishedFolder)
+				})
+			}
 		})
+	}, "file", "name", "size")
+
+	bot.RegisterAction(actionSendFiles, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		sendFinishedFiles(message, params["name"], outputChannel)
+	}, "name")
+
+	bot.RegisterAction(actionTorrentList, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		sortBy := params["sort"]
+		page, _ := strconv.Atoi(params["page"])
+		switch message.Text {
+		case bot.ListPrevious:
+			page--
+		case bot.ListNext:
+			page++
+		default:
+			sortBy = message.Text
+			page = 0
+		}
+		showTorrentList(message, params["view"], sortBy, page, message.MessageID(), outputChannel)
+	}, "view", "sort", "page")
+
+	bot.RegisterAction(actionTorrentControl, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		// buttons of all torrents may be pressed by any member in a group
+		if params["id"] == "" && bot.RoleOf(message) < bot.RoleAdmin {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.AdminOnly)}
+			return
+		}
+		controlTorrents(message, message.Text, params["id"], message.MessageID(), outputChannel)
+	}, "id")
+
+	bot.RegisterAction(actionDeleteTorrent, bot.RoleAdmin, func(message *bot.Info, params map[string]string) {
+		// remove buttons, so the torrent is not deleted twice
+		outputChannel <- bot.OutMessage{OriginalMessage: message, EditMessageID: message.MessageID()}
+		id, err := strconv.ParseInt(params["id"], 10, 64)
+		if err != nil {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidTorrentID, params["id"])}
+			return
+		}
+		deleteTorrent(message, id, message.Text, outputChannel)
+	}, "id")
+
+	bot.RegisterAction(actionTorrentInfo, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		showTorrentInfo(message, params["id"], message.Text, message.MessageID(), outputChannel)
+	}, "id")
+
+	bot.RegisterAction(actionTorrentFiles, bot.RoleMember, func(message *bot.Info, params map[string]string) {
+		id, err := strconv.ParseInt(params["id"], 10, 64)
+		if err != nil {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidTorrentID, params["id"])}
+			return
+		}
+		page, _ := strconv.Atoi(params["page"])
+		mode := params["mode"]
+		switch message.Text {
+		case bot.ListPrevious:
+			page--
+		case bot.ListNext:
+			page++
+		case bot.FilesModeWanted, bot.FilesModeHigh, bot.FilesModeNormal, bot.FilesModeLow:
+			mode = message.Text
+		default:
+			if err := changeFiles(message.Context(), id, message.Text, mode); err != nil {
+				outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ControlError, err)}
+				return
+			}
+		}
+		showTorrentFiles(message, id, page, mode, message.MessageID(), outputChannel)
+	}, "id", "page", "mode")
+
+	bot.RegisterAction(actionSpeed, bot.RoleAdmin, func(message *bot.Info, params map[string]string) {
+		if err := changeSpeed(message.Context(), message.Text); err != nil {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ControlError, err)}
+			return
+		}
+		showSpeed(message, message.MessageID(), outputChannel)
 	})
 
+	bot.RegisterAction(actionLanguage, bot.RoleGuest, func(message *bot.Info, params map[string]string) {
+		setLanguage(message, message.Text, outputChannel)
+	})
+
+	bot.RegisterAction(actionSearch, bot.RoleGuest, func(message *bot.Info, params map[string]string) {
+		showSearchResults(message, params["query"], message.Text, 0, 0, outputChannel)
+	}, "query")
+
+	bot.RegisterAction(actionSearchResults, bot.RoleGuest, func(message *bot.Info, params map[string]string) {
+		if message.Text == bot.MessageMore {
+			maxSize, _ := strconv.ParseInt(params["max_size"], 10, 64)
+			showSearchResults(message, params["query"], params["category"], 1, maxSize, outputChannel)
+		}
+
+		if message.Text == bot.MessageProviderSearch {
+			searchJackett(params["query"], message, outputChannel)
+		}
+
+		if message.Text == bot.MessageSizeLimit {
+			bot.StartConversation(message, stateSizeLimit, map[string]string{"query": params["query"], "category": params["category"]})
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.AskSizeLimit)}
+		}
+	}, "query", "category", "max_size")
+
+	bot.RegisterState(stateSizeLimit, func(message *bot.Info, conversation *bot.Conversation) {
+		maxSize, err := rutracker.ParseSize(message.Text)
+		if err != nil || maxSize <= 0 {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.BadSizeLimit)}
+			return
+		}
+		conversation.Finish()
+		showSearchResults(message, conversation.Data["query"], conversation.Data["category"], 0, maxSize, outputChannel)
+	})
+
+	bot.OnConversationTimeout(func(message *bot.Info) {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ConversationTimeout)}
+	})
+
+	bot.HandleInlineQueries(inlineSearch)
+
+	go func() {
+		if err := bot.PublishCommands(); err != nil {
+			fmt.Println("Publish commands error ", err)
+		}
+	}()
+
 	go bot.Sender(outputChannel)
-	bot.RequestUpdates()
+	watchersPath := config.CreateFilePath(envConfig.DataFolder, "watchers.json")
+	resumeWatches(watchersPath, outputChannel)
+	watchers.start(func() {
+		applySpeedSchedules(workCtx)
+	})
+
+	if envConfig.WebhookUrl != "" {
+		if err := bot.ListenWebhook(ctx, envConfig.WebhookUrl, envConfig.WebhookListen, envConfig.WebhookPath, envConfig.WebhookSecret); err != nil {
+			fmt.Println("Webhook error ", err)
+		}
+	} else {
+		bot.RequestUpdates(ctx)
+	}
+	shutdown(time.Duration(envConfig.ShutdownSeconds)*time.Second, cancelWork, watchersPath)
+}
+
+// finish work before the deadline: running commands may complete, then downloads are cancelled,
+// progress watchers are saved to continue after restart and queued replies are sent
+func shutdown(timeout time.Duration, cancelWork context.CancelFunc, watchersPath string) {
+	fmt.Println("Shutting down")
+	deadline, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	handlersDeadline, cancelHandlers := context.WithTimeout(deadline, timeout/2)
+	if err := bot.WaitForHandlers(handlersDeadline); err != nil {
+		fmt.Println("Cancel running handlers ", err)
+	}
+	cancelHandlers()
+	cancelWork()
+	if err := bot.WaitForHandlers(deadline); err != nil {
+		fmt.Println("Handlers did not stop ", err)
+	}
+	if err := watchers.wait(deadline); err != nil {
+		fmt.Println("Watchers did not stop ", err)
+	}
+	if err := watchers.save(watchersPath); err != nil {
+		fmt.Println("Save watchers error ", err)
+	}
+	if err := bot.Flush(deadline); err != nil {
+		fmt.Println("Replies are not sent ", err)
+	}
+	fmt.Println("Stopped")
 }
 
 func monitorTorrentUpdates(activeFolder transmission.WatchedFolder, originalMessage *bot.Info, outputChannel chan bot.OutMessage, finishedFolder transmission.WatchedFolder) {
-	newFileName, err := activeFolder.WaitForNewFileWithRetry(25)
+	ctx := originalMessage.Context()
+	newFileName, err := activeFolder.WaitForNewFileWithRetry(ctx, 25)
+	if ctx.Err() != nil {
+		fmt.Println("stopped waiting for torrent to start")
+		return
+	}
 	if err != nil {
-		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: "Waited for torrent to start loading, but error: " + err.Error()}
+		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.WaitStartError, err)}
 		outputChannel <- reply
 	} else {
-		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: "Start loading: " + newFileName}
-		outputChannel <- reply
+		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.StartLoading, newFileName)}
+		messageID := sendAndWaitForID(reply, outputChannel)
+
+		// show live progress in the same message if transmission is reachable
+		torrent, err := transmission.FindTorrentByName(ctx, newFileName)
+		if err == nil && messageID != 0 {
+			trackProgress(originalMessage, *torrent.ID, messageID, outputChannel)
+			return
+		}
+		fmt.Println("no progress for torrent ", err)
 	}
 
-	newFileName, err = finishedFolder.WaitForNewFileWithRetry(60 * 60 * 24)
+	newFileName, err = finishedFolder.WaitForNewFileWithRetry(ctx, 60*60*24)
+	if ctx.Err() != nil {
+		fmt.Println("stopped waiting for torrent to finish")
+		return
+	}
 	if err != nil {
-		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: "Waited for torrent to finish, but error: " + err.Error()}
+		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.WaitFinishError, err)}
 		outputChannel <- reply
 	} else {
-		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: fmt.Sprintf("%s finished", newFileName)}
-		outputChannel <- reply
+		outputChannel <- finishedMessage(originalMessage, newFileName)
 	}
 }
 
 func searchTorrent(originalMessage *bot.Info, searchText string, outputChannel chan bot.OutMessage) {
 	fmt.Println("Command .*", searchText)
 	bot.SendTypingStatus(originalMessage)
-	go safeCall(func() {
+	reply := bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.WhereToSearch), UseInlineKeyboard: true, InlineKeyboard: bot.CategoriesKeyboard(originalMessage.Language(), bot.NewAction(actionSearch, map[string]string{"query": searchText}))}
+	outputChannel <- reply
+}
 
-		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: "Где искать?", UseInlineKeyboard: true, InlineKeyboard: bot.CategoriesKeyboard, ReplyCallback: func(data string) {
-			operations.SearchTorrent(searchText, data, func(result operations.OperationResult) {
-				if result.Err != nil {
-					fmt.Println(result.Text)
-					reply := bot.OutMessage{OriginalMessage: originalMessage, Text: result.Text}
-					outputChannel <- reply
-				} else {
-					fmt.Println("search result")
-					// check if items nil or empty
-					if result.Items == nil || len(result.Items) == 0 {
-						searchJackett(searchText, originalMessage, outputChannel)
-						// save := bot.EncodeStringToCommand("save", searchText)
-						// reply := bot.OutMessage{OriginalMessage: originalMessage, Text: fmt.Sprintf("No results, %s", save)}
-						// outputChannel <- reply
-					} else {
-						textBlocks := convertItemsToText(result.Items)
-						if len(textBlocks) > 1 {
-							outputChannel <- bot.OutMessage{OriginalMessage: originalMessage, Html: true, Text: textBlocks[0], UseInlineKeyboard: true, InlineKeyboard: bot.MessageActionKeyboard, ReplyCallback: func(data string) {
-								if data == bot.MessageMore {
-									reply := bot.OutMessage{OriginalMessage: originalMessage, Text: textBlocks[1], Html: true}
-									outputChannel <- reply
-								}
-
-								if data == bot.MessageProviderSearch {
-									searchJackett(searchText, originalMessage, outputChannel)
-								}
-							}}
-						} else {
-							reply := bot.OutMessage{OriginalMessage: originalMessage, Text: textBlocks[0], Html: true}
-							outputChannel <- reply
-						}
-
-						// go makeAiResponse(result, searchText, originalMessage, outputChannel)
+// search rutracker and send block of results with given index, jackett is used if nothing found,
+// results bigger than maxSize bytes are skipped if maxSize is set
+func showSearchResults(originalMessage *bot.Info, searchText string, category string, block int, maxSize int64, outputChannel chan bot.OutMessage) {
+	operations.SearchTorrent(originalMessage.Context(), searchText, category, func(result operations.OperationResult) {
+		if result.Err != nil {
+			fmt.Println(result.Text)
+			reply := bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.SearchError, result.Err)}
+			outputChannel <- reply
+		} else {
+			fmt.Println("search result")
+			// check if items nil or empty
+			if result.Items == nil || len(result.Items) == 0 {
+				searchJackett(searchText, originalMessage, outputChannel)
+				// save := bot.EncodeStringToCommand("save", searchText)
+				// reply := bot.OutMessage{OriginalMessage: originalMessage, Text: fmt.Sprintf("No results, %s", save)}
+				// outputChannel <- reply
+			} else {
+				items := result.Items
+				if maxSize > 0 {
+					items = rutracker.FilterBySize(items, maxSize)
+					if len(items) == 0 {
+						outputChannel <- bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.NoResultsWithSize)}
+						return
 					}
 				}
-			})
-		}}
-		outputChannel <- reply
-	}, func(s string) {
-		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: s}
-		outputChannel <- reply
+
+				textBlocks := convertItemsToText(items, originalMessage.Language())
+				if block >= len(textBlocks) {
+					block = len(textBlocks) - 1
+				}
+				params := map[string]string{"query": searchText, "category": category, "max_size": strconv.FormatInt(maxSize, 10)}
+				if block == 0 && len(textBlocks) > 1 {
+					outputChannel <- bot.OutMessage{OriginalMessage: originalMessage, Html: true, Text: textBlocks[0], UseInlineKeyboard: true, InlineKeyboard: bot.MessageActionKeyboard(originalMessage.Language(), bot.NewAction(actionSearchResults, params))}
+				} else if block == 0 {
+					outputChannel <- bot.OutMessage{OriginalMessage: originalMessage, Html: true, Text: textBlocks[0], UseInlineKeyboard: true, InlineKeyboard: bot.MessageFilterKeyboard(originalMessage.Language(), bot.NewAction(actionSearchResults, params))}
+				} else {
+					reply := bot.OutMessage{OriginalMessage: originalMessage, Text: textBlocks[block], Html: true}
+					outputChannel <- reply
+				}
+
+				// go makeAiResponse(result, searchText, originalMessage, outputChannel)
+			}
+		}
 	})
 }
 
 func searchJackett(searchText string, originalMessage *bot.Info, outputChannel chan bot.OutMessage) {
-	jacketClient, err := jackett.GetClient()
+	ctx := originalMessage.Context()
+	jacketClient, err := jackett.GetClient(ctx)
 	if err != nil {
 		fmt.Println("No jackett ", err)
 	} else {
 		fmt.Println("Jackett found")
 	}
 
-	lastJackettRequestResults = make(map[int]jackett.Result)
-	ctx := context.Background()
+	lastJackettRequestResults.set(originalMessage.ChatID(), nil)
 	input := &jackett.FetchRequest{Query: searchText}
 	response, err := jacketClient.Fetch(ctx, input)
 	if err != nil {
-		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: fmt.Sprintf("Error searching: %v", err)}
+		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.SearchError, err)}
 		outputChannel <- reply
 		return
 	}
 
 	if len(response.Results) == 0 {
-		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: "No results found"}
+		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.NoResults)}
 		outputChannel <- reply
 		return
 	}
@@ -405,45 +815,273 @@ func searchJackett(searchText string, originalMessage *bot.Info, outputChannel c
 	var results []string
 	id := 0
 	for _, result := range response.Results {
-		lastJackettRequestResults[id] = result
-		results = append(results, fmt.Sprintf("Title: %s\nSize: %d\nSeeders: %d\nDownload: /download_%d",
+		results = append(results, originalMessage.T(i18n.JackettItem,
 			result.Title, result.Size, result.Seeders, id))
 		id = id + 1
 	}
 
+	lastJackettRequestResults.set(originalMessage.ChatID(), response.Results)
+
 	reply := bot.OutMessage{OriginalMessage: originalMessage, Text: strings.Join(results, "\n\n")}
 	outputChannel <- reply
 }
 
-func showTorrentList(message *bot.Info, outputChannel chan bot.OutMessage) {
-	ok, err := transmission.CheckRPCConnection()
+// search for inline query, jackett is used if nothing found on rutracker
+func inlineSearch(message *bot.Info) []bot.InlineArticle {
+	items, err := rutracker.SearchEverywhere(message.Context(), message.Text)
 	if err != nil {
-		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: "Error connecting to transmission: " + err.Error()}
+		fmt.Println("inline search error ", err)
+	}
+	if len(items) > 0 {
+		return convertItemsToArticles(items, message.Language())
+	}
+
+	jacketClient, err := jackett.GetClient(message.Context())
+	if err != nil {
+		fmt.Println("No jackett ", err)
+		return nil
+	}
+	response, err := jacketClient.Fetch(message.Context(), &jackett.FetchRequest{Query: message.Text})
+	if err != nil {
+		fmt.Println("inline jackett error ", err)
+		return nil
+	}
+	return convertJackettToArticles(response.Results, message.Language())
+}
+
+// letters and digits of the title, everything else is replaced with _
+func safeFileName(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return r
+		}
+		return '_'
+	}, title)
+}
+
+// ask what to do with rutracker topic
+func showTopicActions(message *bot.Info, topicId string, outputChannel chan bot.OutMessage) {
+	bot.SendTypingStatus(message)
+	reply := bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.WhatToDo), UseInlineKeyboard: true, InlineKeyboard: bot.DownloadActionKeyboard(message.Language(), bot.NewAction(actionTopicDownload, map[string]string{"topic": topicId}))}
+	outputChannel <- reply
+}
+
+// add magnet to transmission and show its progress
+func addMagnet(message *bot.Info, magnetUri string, outputChannel chan bot.OutMessage) {
+	torrentID, err := transmission.AddTorrent(message.Context(), magnetUri)
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.AddTorrentError, err)}
+		return
+	}
+	messageID := sendAndWaitForID(bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DownloadingMagnet)}, outputChannel)
+	watchers.start(func() {
+		trackProgress(message, torrentID, messageID, outputChannel)
+	})
+}
+
+// remember language of the user and reply in it
+func setLanguage(message *bot.Info, lang string, outputChannel chan bot.OutMessage) {
+	if !i18n.Supported(lang) {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.UnknownLanguage, strings.Join(i18n.Languages(), ", "))}
+		return
+	}
+	if err := i18n.SetUserLanguage(message.UserID(), lang); err != nil {
+		fmt.Println("save language error ", err)
+	}
+	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.LanguageChanged)}
+}
+
+// send page of downloading or finished torrents with paging and sort buttons,
+// the message is edited if editMessageID is set
+func showTorrentList(message *bot.Info, view string, sortBy string, page int, editMessageID int, outputChannel chan bot.OutMessage) {
+	ctx := message.Context()
+	ok, err := transmission.CheckRPCConnection(ctx)
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.TransmissionError, err)}
 		return
 	}
 	if !ok {
-		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: "Could not connect to transmission"}
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.TransmissionUnavailable)}
+		return
+	}
+
+	torrentView := transmission.ViewDownloading
+	if view == viewFinished {
+		torrentView = transmission.ViewFinished
+	}
+	torrents, err := transmission.ListTorrents(ctx, torrentView, sortBy)
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.TransmissionError, err)}
+		return
+	}
+
+	text, page, pages := formatTorrentList(torrents, torrentView, page, message.Language())
+	if len(torrents) == 0 {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text, EditMessageID: editMessageID}
+		return
+	}
+	params := map[string]string{"view": view, "sort": sortBy, "page": strconv.Itoa(page)}
+	keyboard := bot.TorrentListKeyboard(message.Language(), bot.NewAction(actionTorrentList, params), page, pages, sortBy)
+	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text, Html: true, UseInlineKeyboard: true, InlineKeyboard: keyboard, EditMessageID: editMessageID}
+}
+
+// remove torrent after user chose to delete it with or without files, or cancelled,
+// the choice is written to audit trail
+func deleteTorrent(message *bot.Info, id int64, choice string, outputChannel chan bot.OutMessage) {
+	record := operations.AuditRecord{UserID: message.UserID(), ChatID: message.ChatID(), TorrentID: id}
+	if torrent, err := transmission.GetTorrent(message.Context(), id); err == nil {
+		if torrent.Name != nil {
+			record.Name = *torrent.Name
+		}
+		if torrent.SizeWhenDone != nil {
+			record.Size = int64(torrent.SizeWhenDone.Byte())
+		}
+	}
+
+	var ok bool
+	var err error
+	switch choice {
+	case bot.DeleteTorrent:
+		record.Action = "delete"
+		ok, err = transmission.RemoveTorrent(message.Context(), id, false)
+	case bot.DeleteWithFiles:
+		record.Action = "delete"
+		record.DeleteData = true
+		ok, err = transmission.RemoveTorrent(message.Context(), id, true)
+	default:
+		record.Action = "cancel_delete"
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if auditErr := operations.Audit(record); auditErr != nil {
+		fmt.Println("audit error ", auditErr)
+	}
+
+	switch {
+	case record.Action == "cancel_delete":
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.Cancelled)}
+	case err != nil:
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DeleteError, err)}
+	case !ok:
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DeleteFailed)}
+	case record.DeleteData:
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.DeletedWithFiles, record.Name)}
+	default:
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.Deleted, record.Name)}
+	}
+}
+
+// send section of torrent details with buttons of other sections, the message is edited if editMessageID is set
+func showTorrentInfo(message *bot.Info, id string, section string, editMessageID int, outputChannel chan bot.OutMessage) {
+	torrentID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidTorrentID, id)}
 		return
 	}
-	torrents, err := transmission.GetAllTorrentsAsString()
+	torrent, err := transmission.GetTorrentDetails(message.Context(), torrentID)
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.TransmissionError, err)}
+		return
+	}
+
+	text := formatTorrentInfo(torrent, section, message.Language())
+	keyboard := bot.TorrentInfoKeyboard(message.Language(), bot.NewAction(actionTorrentInfo, map[string]string{"id": id}), section)
+	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text, Html: true, UseInlineKeyboard: true, InlineKeyboard: keyboard, EditMessageID: editMessageID}
+}
+
+// send page of torrent files with buttons to choose them, the message is edited if editMessageID is set
+func showTorrentFiles(message *bot.Info, id int64, page int, mode string, editMessageID int, outputChannel chan bot.OutMessage) {
+	torrent, err := transmission.GetTorrentFiles(message.Context(), id)
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.TransmissionError, err)}
+		return
+	}
+	if len(torrent.Files) == 0 {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NoTorrentFiles), EditMessageID: editMessageID}
+		return
+	}
+
+	text, choices, page, pages := formatFileChoices(torrent, page, mode, message.Language())
+	params := map[string]string{"id": strconv.FormatInt(id, 10), "page": strconv.Itoa(page), "mode": mode}
+	keyboard := bot.FilesKeyboard(message.Language(), bot.NewAction(actionTorrentFiles, params), choices, page, pages, mode)
+	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text, Html: true, UseInlineKeyboard: true, InlineKeyboard: keyboard, EditMessageID: editMessageID}
+}
+
+// apply pressed button of file selection: all or none of files, or the file is toggled or gets priority of the mode
+func changeFiles(ctx context.Context, id int64, button string, mode string) error {
+	if button == bot.FilesAll || button == bot.FilesNone {
+		torrent, err := transmission.GetTorrentFiles(ctx, id)
+		if err != nil {
+			return err
+		}
+		indexes := make([]int64, len(torrent.Files))
+		for i := range indexes {
+			indexes[i] = int64(i)
+		}
+		return transmission.SetFilesWanted(ctx, id, indexes, button == bot.FilesAll)
+	}
+
+	index, err := strconv.ParseInt(strings.TrimPrefix(button, bot.FilePrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(button, bot.FilePrefix) {
+		return fmt.Errorf("unknown button %s", button)
+	}
+	switch mode {
+	case bot.FilesModeHigh:
+		return transmission.SetFilesPriority(ctx, id, []int64{index}, transmission.PriorityHigh)
+	case bot.FilesModeNormal:
+		return transmission.SetFilesPriority(ctx, id, []int64{index}, transmission.PriorityNormal)
+	case bot.FilesModeLow:
+		return transmission.SetFilesPriority(ctx, id, []int64{index}, transmission.PriorityLow)
+	}
+
+	torrent, err := transmission.GetTorrentFiles(ctx, id)
 	if err != nil {
-		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: err.Error()}
+		return err
+	}
+	wanted := true
+	if index < int64(len(torrent.FileStats)) {
+		wanted = torrent.FileStats[index].Wanted
+	}
+	return transmission.SetFilesWanted(ctx, id, []int64{index}, !wanted)
+}
+
+// stop, start, verify or reannounce torrent with id, or all torrents if id is empty,
+// reply shows the status after the action and buttons for next actions
+func controlTorrents(message *bot.Info, action string, id string, editMessageID int, outputChannel chan bot.OutMessage) {
+	var ids []int64
+	if id != "" {
+		torrentID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.InvalidTorrentID, id)}
+			return
+		}
+		ids = []int64{torrentID}
+	}
+
+	torrents, err := transmission.ControlTorrents(message.Context(), action, ids)
+	if err != nil {
+		fmt.Println("control torrents error ", err)
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ControlError, err)}
 		return
 	}
-	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: torrents}
+
+	text := formatControlResult(torrents, id == "", message.Language())
+	keyboard := bot.TorrentControlKeyboard(message.Language(), bot.NewAction(actionTorrentControl, map[string]string{"id": id}))
+	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: text, Html: true, UseInlineKeyboard: true, InlineKeyboard: keyboard, EditMessageID: editMessageID}
 }
 
 func makeAiResponse(result operations.OperationResult, searchText string, originalMessage *bot.Info, outputChannel chan bot.OutMessage) {
 	prompt := convertItemsToPrompt(result.Items, searchText)
 	fmt.Println(prompt)
-	ai_result, ai_error := ai.GenerateAiResponse(prompt)
+	ai_result, ai_error := ai.GenerateAiResponse(originalMessage.Context(), prompt)
 	if ai_error != nil {
 		fmt.Println(ai_error)
 		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: ai_error.Error()}
 		outputChannel <- reply
 	} else {
 		fmt.Println(ai_result)
-		aiOutput := fmt.Sprintf("<b>Совет искуственного интеллекта:</b>\n%s", ai_result)
+		aiOutput := originalMessage.T(i18n.AiAdvice, ai_result)
 		reply := bot.OutMessage{OriginalMessage: originalMessage, Text: aiOutput, Html: true}
 		outputChannel <- reply
 	}
@@ -2,17 +2,27 @@ package main
 
 import (
 	"fmt"
+	"html"
+	"path"
+	"strconv"
+	"strings"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/telegram-command-reader/bot"
+	"github.com/telegram-command-reader/i18n"
+	"github.com/telegram-command-reader/operations/jackett"
 	rutracker "github.com/telegram-command-reader/operations/rutracker"
+	transmission "github.com/telegram-command-reader/operations/transmission"
 )
 
-func convertItemsToText(items []rutracker.TorrentItem) []string {
+func convertItemsToText(items []rutracker.TorrentItem, lang string) []string {
 	result := []string{}
 	lines := ""
 	for i := len(items) - 1; i >= 0; i-- {
 		// url to open instant view with torrent details https://instantview.telegram.org/
 		url := fmt.Sprintf("https://t.me/iv?url=https://rutracker.org/forum/viewtopic.php?t=%s&rhash=4625e276e6dfbf", items[i].TopicId)
-		nextItem := fmt.Sprintf("%s\n<b>Size:%s</b>,Seeds:%s,%s\n/%s			<a href=\"%s\">details</a>\n\n",
+		nextItem := i18n.T(lang, i18n.SearchItem,
 			items[i].Title,
 			items[i].Size,
 			items[i].Seeds,
@@ -35,6 +45,47 @@ func convertItemsToText(items []rutracker.TorrentItem) []string {
 	return result
 }
 
+// results for inline mode, the posted card has button to download the topic on server
+func convertItemsToArticles(items []rutracker.TorrentItem, lang string) []bot.InlineArticle {
+	var articles []bot.InlineArticle
+	for _, item := range items {
+		url := rutracker.TopicUrl(item.TopicId)
+		action := bot.NewAction(actionTopicDownload, map[string]string{"topic": item.TopicId})
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			bot.InlineDownloadKeyboard(lang, action).InlineKeyboard[0],
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(i18n.T(lang, i18n.ButtonOpenRutracker), url)),
+		)
+		articles = append(articles, bot.InlineArticle{
+			Title:          item.Title,
+			Description:    i18n.T(lang, i18n.ArticleDescription, item.Size, item.Seeds, item.Category),
+			Text:           i18n.T(lang, i18n.ArticleText, html.EscapeString(item.Title), item.Size, item.Seeds, url),
+			Html:           true,
+			InlineKeyboard: &keyboard,
+		})
+	}
+	return articles
+}
+
+// inline results of jackett, only results with torrent file link can be downloaded from the card
+func convertJackettToArticles(results []jackett.Result, lang string) []bot.InlineArticle {
+	var articles []bot.InlineArticle
+	for _, result := range results {
+		if result.Link == "" {
+			continue
+		}
+		keyboard := bot.InlineDownloadKeyboard(lang, bot.NewAction(actionJackettDownload, map[string]string{"link": result.Link, "title": result.Title}))
+		size := transmission.FormatBytes(int64(result.Size))
+		articles = append(articles, bot.InlineArticle{
+			Title:          result.Title,
+			Description:    i18n.T(lang, i18n.JackettDescription, size, result.Seeders, result.Tracker),
+			Text:           i18n.T(lang, i18n.JackettText, html.EscapeString(result.Title), size, result.Seeders, html.EscapeString(result.Tracker)),
+			Html:           true,
+			InlineKeyboard: &keyboard,
+		})
+	}
+	return articles
+}
+
 func convertItemsToPrompt(items []rutracker.TorrentItem, searchQuery string) string {
 	MAX_RES := 15
 	if MAX_RES > len(items) {
@@ -65,3 +116,294 @@ func convertItemsToPrompt(items []rutracker.TorrentItem, searchQuery string) str
 
 	return prompt
 }
+
+// how many of the biggest files are listed in torrent summary
+const maxSummaryFiles = 5
+
+// files, peers or trackers listed in one section of torrent details
+const maxInfoItems = 20
+
+// files on one page of file selection, long names are cut in buttons
+const (
+	filesPerPage      = 8
+	maxFileNameLength = 40
+)
+
+// torrents on one page of /downloading and /finished
+const torrentsPerPage = 10
+
+// count of trackers with first of their hosts, like "3 (bt.t-ru.org, tracker.opentrackr.org)"
+func formatTrackers(count int, hosts []string) string {
+	trackers := strconv.Itoa(count)
+	if len(hosts) > 3 {
+		hosts = append(hosts[:3:3], "...")
+	}
+	if len(hosts) > 0 {
+		trackers += " (" + strings.Join(hosts, ", ") + ")"
+	}
+	return trackers
+}
+
+// html summary of uploaded .torrent file with question to download it
+func formatTorrentSummary(torrent transmission.TorrentInfo, lang string) string {
+	name := torrent.Name
+	if name == "" {
+		name = i18n.T(lang, i18n.Unnamed)
+	}
+	private := i18n.T(lang, i18n.No)
+	if torrent.Private {
+		private = i18n.T(lang, i18n.Yes)
+	}
+
+	var files strings.Builder
+	for i, file := range torrent.Files {
+		if i == maxSummaryFiles {
+			files.WriteString(i18n.T(lang, i18n.MoreFiles, len(torrent.Files)-maxSummaryFiles))
+			break
+		}
+		files.WriteString(i18n.T(lang, i18n.TorrentFileItem, html.EscapeString(file.Path), transmission.FormatBytes(file.Size)))
+	}
+
+	return i18n.T(lang, i18n.TorrentSummary,
+		html.EscapeString(name),
+		transmission.FormatBytes(torrent.TotalSize),
+		len(torrent.Files),
+		transmission.FormatBytes(torrent.PieceLength),
+		private,
+		torrent.InfoHash,
+		html.EscapeString(formatTrackers(len(torrent.Trackers), torrent.TrackerHosts())),
+		files.String())
+}
+
+// html page of /downloading or /finished, page is from 0 and is moved into the list,
+// returns text, the shown page and count of pages
+func formatTorrentList(torrents []transmissionrpc.Torrent, view transmission.TorrentView, page int, lang string) (string, int, int) {
+	if len(torrents) == 0 {
+		if view == transmission.ViewFinished {
+			return i18n.T(lang, i18n.NoFinished), 0, 1
+		}
+		return i18n.T(lang, i18n.NoDownloading), 0, 1
+	}
+
+	pages := (len(torrents) + torrentsPerPage - 1) / torrentsPerPage
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	end := (page + 1) * torrentsPerPage
+	if end > len(torrents) {
+		end = len(torrents)
+	}
+
+	var result strings.Builder
+	for _, t := range torrents[page*torrentsPerPage : end] {
+		name := ""
+		if t.Name != nil {
+			name = *t.Name
+		}
+		var id int64
+		if t.ID != nil {
+			id = *t.ID
+		}
+		// stopped torrent can be started, others can be stopped
+		control := fmt.Sprintf("/%s_%d", transmission.ActionStop, id)
+		if t.Status != nil && *t.Status == transmissionrpc.TorrentStatusStopped {
+			control = fmt.Sprintf("/%s_%d", transmission.ActionStart, id)
+		}
+		if view == transmission.ViewFinished {
+			result.WriteString(i18n.T(lang, i18n.FinishedItem, html.EscapeString(name), transmission.SizeString(t), transmission.RatioString(t), transmission.StatusString(t, lang), id, control, id))
+		} else {
+			percent := 0.0
+			if t.PercentDone != nil {
+				percent = *t.PercentDone * 100
+			}
+			result.WriteString(i18n.T(lang, i18n.DownloadingItem, html.EscapeString(name), percent, transmission.SpeedString(t, lang), transmission.StatusString(t, lang), id, control, id))
+		}
+	}
+	if pages > 1 {
+		result.WriteString(i18n.T(lang, i18n.ListPage, page+1, pages))
+	}
+	return result.String(), page, pages
+}
+
+// html result of control command, one torrent with its status or counts of statuses for all torrents
+func formatControlResult(torrents []transmissionrpc.Torrent, all bool, lang string) string {
+	if all {
+		return i18n.T(lang, i18n.ControlAllResult, html.EscapeString(transmission.StatusCounts(torrents, lang)))
+	}
+	var lines []string
+	for _, t := range torrents {
+		name := ""
+		if t.Name != nil {
+			name = *t.Name
+		}
+		lines = append(lines, i18n.T(lang, i18n.ControlResult, html.EscapeString(name), transmission.StatusString(t, lang)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// html section of torrent details: summary with dates and errors, files, peers or trackers
+func formatTorrentInfo(t transmissionrpc.Torrent, section string, lang string) string {
+	name := ""
+	if t.Name != nil {
+		name = html.EscapeString(*t.Name)
+	}
+
+	var result strings.Builder
+	switch section {
+	case bot.InfoFiles:
+		result.WriteString("<b>" + name + "</b>\n")
+		for i, file := range t.Files {
+			if i == maxInfoItems {
+				result.WriteString(i18n.T(lang, i18n.MoreFiles, len(t.Files)-maxInfoItems))
+				break
+			}
+			result.WriteString(i18n.T(lang, i18n.InfoFileItem, transmission.FileProgress(file)*100, html.EscapeString(file.Name), transmission.FormatBytes(file.Length)))
+		}
+	case bot.InfoPeers:
+		result.WriteString("<b>" + name + "</b>\n")
+		if len(t.Peers) == 0 {
+			result.WriteString(i18n.T(lang, i18n.NoPeers))
+		}
+		for i, peer := range t.Peers {
+			if i == maxInfoItems {
+				result.WriteString(i18n.T(lang, i18n.MoreFiles, len(t.Peers)-maxInfoItems))
+				break
+			}
+			result.WriteString(i18n.T(lang, i18n.InfoPeerItem, html.EscapeString(peer.Address), html.EscapeString(peer.ClientName),
+				transmission.FormatBytes(peer.RateToClient), transmission.FormatBytes(peer.RateToPeer), peer.Progress*100))
+		}
+	case bot.InfoTrackers:
+		result.WriteString("<b>" + name + "</b>\n")
+		if len(t.TrackerStats) == 0 {
+			result.WriteString(i18n.T(lang, i18n.NoTrackers))
+		}
+		for i, tracker := range t.TrackerStats {
+			if i == maxInfoItems {
+				result.WriteString(i18n.T(lang, i18n.MoreFiles, len(t.TrackerStats)-maxInfoItems))
+				break
+			}
+			result.WriteString(i18n.T(lang, i18n.InfoTrackerItem, html.EscapeString(tracker.Host), tracker.SeederCount, tracker.LeecherCount))
+			if tracker.HasAnnounced && !tracker.LastAnnounceSucceeded && tracker.LastAnnounceResult != "" {
+				result.WriteString(i18n.T(lang, i18n.InfoTrackerError, html.EscapeString(tracker.LastAnnounceResult)))
+			}
+		}
+	default:
+		percent := 0.0
+		if t.PercentDone != nil {
+			percent = *t.PercentDone * 100
+		}
+		var down, up int64
+		if t.RateDownload != nil {
+			down = *t.RateDownload
+		}
+		if t.RateUpload != nil {
+			up = *t.RateUpload
+		}
+		folder := ""
+		if t.DownloadDir != nil {
+			folder = *t.DownloadDir
+		}
+		result.WriteString(i18n.T(lang, i18n.InfoSummary, name, percent, transmission.StatusString(t, lang),
+			transmission.FormatBytes(down), transmission.FormatBytes(up), transmission.ETAString(t, lang),
+			transmission.SizeString(t), transmission.RatioString(t), html.EscapeString(folder),
+			transmission.FormatDate(t.AddedDate), transmission.FormatDate(t.DoneDate)))
+		if t.ErrorString != nil && *t.ErrorString != "" {
+			result.WriteString(i18n.T(lang, i18n.InfoError, html.EscapeString(*t.ErrorString)))
+		}
+	}
+	return result.String()
+}
+
+// html header of file selection and buttons of files on the page, page is moved into the list,
+// returns text, buttons, the shown page and count of pages
+func formatFileChoices(t transmissionrpc.Torrent, page int, mode string, lang string) (string, []bot.FileChoice, int, int) {
+	name := ""
+	if t.Name != nil {
+		name = *t.Name
+	}
+	hint := i18n.T(lang, i18n.FilesHintWanted)
+	switch mode {
+	case bot.FilesModeHigh:
+		hint = i18n.T(lang, i18n.FilesHintPriority, i18n.T(lang, i18n.ButtonPriorityHigh))
+	case bot.FilesModeNormal:
+		hint = i18n.T(lang, i18n.FilesHintPriority, i18n.T(lang, i18n.ButtonPriorityNormal))
+	case bot.FilesModeLow:
+		hint = i18n.T(lang, i18n.FilesHintPriority, i18n.T(lang, i18n.ButtonPriorityLow))
+	}
+	count, size := transmission.WantedFiles(t)
+	text := i18n.T(lang, i18n.FilesHeader, html.EscapeString(name), count, len(t.Files), transmission.FormatBytes(size), hint)
+
+	pages := (len(t.Files) + filesPerPage - 1) / filesPerPage
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	var choices []bot.FileChoice
+	for i := page * filesPerPage; i < len(t.Files) && i < (page+1)*filesPerPage; i++ {
+		wanted, priority := true, transmission.PriorityNormal
+		if i < len(t.FileStats) {
+			wanted, priority = t.FileStats[i].Wanted, t.FileStats[i].Priority
+		}
+		mark := "⬜ "
+		if wanted {
+			mark = "✅ "
+		}
+		if priority == transmission.PriorityHigh {
+			mark += "⬆ "
+		} else if priority == transmission.PriorityLow {
+			mark += "⬇ "
+		}
+		file := t.Files[i]
+		choices = append(choices, bot.FileChoice{Index: i, Text: mark + shortFileName(file.Name) + " — " + transmission.FormatBytes(file.Length)})
+	}
+	return text, choices, page, pages
+}
+
+// last part of the file path, long names keep their end where episode numbers usually are
+func shortFileName(filePath string) string {
+	name := []rune(path.Base(filePath))
+	if len(name) > maxFileNameLength {
+		return "…" + string(name[len(name)-maxFileNameLength:])
+	}
+	return string(name)
+}
+
+// limit like 2 MB/s, or unlimited if it is not enabled
+func formatSpeedLimit(limit int64, enabled bool, lang string) string {
+	if !enabled {
+		return i18n.T(lang, i18n.SpeedUnlimited)
+	}
+	return transmission.SpeedLimitString(limit)
+}
+
+// global limits and turtle mode with its limits
+func formatSpeedSettings(settings transmission.SpeedSettings, lang string) string {
+	turtle := i18n.T(lang, i18n.No)
+	if settings.AltEnabled {
+		turtle = i18n.T(lang, i18n.Yes)
+	}
+	return i18n.T(lang, i18n.SpeedStatus,
+		formatSpeedLimit(settings.Limits.Down, settings.Limits.DownEnabled, lang),
+		formatSpeedLimit(settings.Limits.Up, settings.Limits.UpEnabled, lang),
+		turtle, transmission.SpeedLimitString(settings.AltDown), transmission.SpeedLimitString(settings.AltUp))
+}
+
+// numbered schedules with commands to remove them, numbers are from 1
+func formatSchedules(schedules []transmission.SpeedSchedule, lang string) string {
+	if len(schedules) == 0 {
+		return i18n.T(lang, i18n.NoSchedules)
+	}
+	var result strings.Builder
+	result.WriteString(i18n.T(lang, i18n.SchedulesHeader) + "\n")
+	for i, schedule := range schedules {
+		result.WriteString(i18n.T(lang, i18n.ScheduleItem, i+1, schedule.Hours(),
+			formatSpeedLimit(schedule.Limits.Down, schedule.Limits.DownEnabled, lang),
+			formatSpeedLimit(schedule.Limits.Up, schedule.Limits.UpEnabled, lang), i+1))
+	}
+	return result.String()
+}
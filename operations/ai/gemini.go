@@ -13,9 +13,7 @@ var API_KEY string
 
 // GenerateAiResponse uses the GenAI client to generate content based on the given text.
 // It requires an API key and the text for content generation as inputs.
-func GenerateAiResponse(text string) (string, error) {
-	ctx := context.Background()
-
+func GenerateAiResponse(ctx context.Context, text string) (string, error) {
 	// Set up the client with the provided API key
 	client, err := genai.NewClient(ctx, option.WithAPIKey(API_KEY))
 	if err != nil {
@@ -30,7 +28,8 @@ func GenerateAiResponse(text string) (string, error) {
 	// Generate content based on the provided text
 	resp, err := model.GenerateContent(ctx, genai.Text(text))
 	if resp == nil && err != nil {
-		log.Fatalf("Failed to generate content: %v", err)
+		// cancelled request must not stop the bot
+		log.Printf("Failed to generate content: %v", err)
 		return "", err
 	}
 
@@ -0,0 +1,52 @@
+package operations
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// file of audit trail, records are not written if it is empty
+var AUDIT_LOG string
+
+var auditMutex sync.Mutex
+
+// AuditRecord is a destructive action of a user, saved as one json line
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	UserID     int64     `json:"user_id"`
+	ChatID     int64     `json:"chat_id"`
+	Action     string    `json:"action"`
+	TorrentID  int64     `json:"torrent_id,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Size       int64     `json:"size,omitempty"`
+	DeleteData bool      `json:"delete_data,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// append record to audit trail, time is set if it is zero
+func Audit(record AuditRecord) error {
+	if AUDIT_LOG == "" {
+		return nil
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	file, err := os.OpenFile(AUDIT_LOG, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
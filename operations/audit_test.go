@@ -0,0 +1,37 @@
+package operations
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditAppendsLines(t *testing.T) {
+	AUDIT_LOG = filepath.Join(t.TempDir(), "audit.log")
+	defer func() { AUDIT_LOG = "" }()
+
+	if err := Audit(AuditRecord{UserID: 1, Action: "delete", TorrentID: 5, Name: "Matrix", DeleteData: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Audit(AuditRecord{UserID: 2, Action: "delete", TorrentID: 6, Error: "not found"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(AUDIT_LOG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", data)
+	}
+	var record AuditRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Name != "Matrix" || !record.DeleteData || record.Time.IsZero() {
+		t.Fatalf("not expected record %+v", record)
+	}
+}
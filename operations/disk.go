@@ -0,0 +1,31 @@
+package operations
+
+import "errors"
+
+// DiskSpace is free and total bytes of the file system with the folder
+type DiskSpace struct {
+	Free  int64
+	Total int64
+}
+
+var ErrDiskSpaceUnsupported = errors.New("disk space is not known on this system")
+
+// result of comparing size of download with free space
+type SpaceCheck int
+
+const (
+	SpaceEnough    SpaceCheck = iota
+	SpaceLow                  // download fits, but less than reserve is left after it
+	SpaceNotEnough            // download does not fit
+)
+
+// compare size of download with free space, reserve is space which should stay free
+func CheckSpace(size int64, free int64, reserve int64) SpaceCheck {
+	switch {
+	case size > free:
+		return SpaceNotEnough
+	case free-size < reserve:
+		return SpaceLow
+	}
+	return SpaceEnough
+}
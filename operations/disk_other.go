@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd)
+
+package operations
+
+// statfs is not available, free space is known only from transmission
+func LocalDiskSpace(path string) (DiskSpace, error) {
+	return DiskSpace{}, ErrDiskSpaceUnsupported
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package operations
+
+import "syscall"
+
+// free space for unprivileged user and size of the file system with the folder
+func LocalDiskSpace(path string) (DiskSpace, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskSpace{}, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return DiskSpace{Free: int64(uint64(stat.Bavail) * blockSize), Total: int64(uint64(stat.Blocks) * blockSize)}, nil
+}
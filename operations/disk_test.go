@@ -0,0 +1,39 @@
+package operations
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckSpace(t *testing.T) {
+	cases := []struct {
+		size, free, reserve int64
+		expected            SpaceCheck
+	}{
+		{size: 10, free: 100, reserve: 20, expected: SpaceEnough},
+		{size: 90, free: 100, reserve: 20, expected: SpaceLow},
+		{size: 100, free: 100, reserve: 0, expected: SpaceEnough},
+		{size: 101, free: 100, reserve: 0, expected: SpaceNotEnough},
+	}
+	for _, c := range cases {
+		if actual := CheckSpace(c.size, c.free, c.reserve); actual != c.expected {
+			t.Fatalf("%+v: actual %d", c, actual)
+		}
+	}
+}
+
+func TestLocalDiskSpace(t *testing.T) {
+	space, err := LocalDiskSpace(t.TempDir())
+	if errors.Is(err, ErrDiskSpaceUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if space.Total <= 0 || space.Free < 0 || space.Free > space.Total {
+		t.Fatalf("not expected %+v", space)
+	}
+	if _, err := LocalDiskSpace("/not/existing/folder"); err == nil {
+		t.Fatal("expected error for missing folder")
+	}
+}
@@ -1,17 +1,26 @@
 package operations
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+
+	"github.com/telegram-command-reader/operations/transmission"
 )
 
 // DownloadFile will download a url to a local file. It's efficient because it will
 // write as it downloads and not load the whole file into memory.
-func DownloadFile(filepath string, url string) error {
+func DownloadFile(ctx context.Context, filepath string, url string) error {
 
 	// Get the data
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -28,3 +37,34 @@ func DownloadFile(filepath string, url string) error {
 	_, err = io.Copy(out, resp.Body)
 	return err
 }
+
+// telegram bot api does not give files bigger than 20 MB
+const maxTorrentFileSize = 20 << 20
+
+// InspectTorrent downloads .torrent file to memory and reads its metainfo
+func InspectTorrent(ctx context.Context, url string) (transmission.TorrentInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return transmission.TorrentInfo{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return transmission.TorrentInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return transmission.TorrentInfo{}, fmt.Errorf("download torrent file: %s", resp.Status)
+	}
+	return transmission.ParseTorrent(io.LimitReader(resp.Body, maxTorrentFileSize))
+}
+
+// ReadTorrent reads .torrent file from stream to memory and parses it, the stream is closed
+func ReadTorrent(stream io.ReadCloser) ([]byte, transmission.TorrentInfo, error) {
+	defer stream.Close()
+	data, err := io.ReadAll(io.LimitReader(stream, maxTorrentFileSize))
+	if err != nil {
+		return nil, transmission.TorrentInfo{}, err
+	}
+	torrent, err := transmission.ParseTorrent(bytes.NewReader(data))
+	return data, torrent, err
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -126,12 +127,12 @@ type Jackett struct {
 
 // http://10.0.4.124:49158
 func getTransmissionUriString(port int) string {
-	return fmt.Sprintf("http://%s:%d", JACKET_URI, port)
+	return "http://" + net.JoinHostPort(JACKET_URI, strconv.Itoa(port))
 }
 
-func GetClient() (*Jackett, error) {
+func GetClient(ctx context.Context) (*Jackett, error) {
 	if client != nil {
-		err := client.pingServer(context.Background())
+		err := client.pingServer(ctx)
 		if err == nil {
 			return client, nil
 		} else {
@@ -145,10 +146,11 @@ func GetClient() (*Jackett, error) {
 		for port := JACKET_PORT_FROM; port <= JACKET_PORT_TO; port++ {
 			endpoint := getTransmissionUriString(port)
 			fmt.Println("jackett checking uri ", endpoint)
-			conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", JACKET_URI, port))
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(JACKET_URI, strconv.Itoa(port)))
 			if err == nil {
 				conn.Close()
-				client, err = makeClient(endpoint)
+				client, err = makeClient(ctx, endpoint)
 				if err != nil {
 					continue
 				} else {
@@ -165,14 +167,14 @@ func GetClient() (*Jackett, error) {
 }
 
 // uri in format http://127.0.0.1:9091/transmission/rpc
-func makeClient(uri string) (*Jackett, error) {
+func makeClient(ctx context.Context, uri string) (*Jackett, error) {
 	_, err := url.Parse(uri)
 	if err != nil {
 		return nil, err
 	}
 
 	jackett := newJackett(uri)
-	err = jackett.pingServer(context.Background())
+	err = jackett.pingServer(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -276,8 +278,8 @@ func (j *Jackett) pingServer(ctx context.Context) error {
 // 	}
 // }
 
-func DownloadTorrentFile(filepath string, url string) error {
-	res, err := http.NewRequest("GET", url, nil)
+func DownloadTorrentFile(ctx context.Context, filepath string, url string) error {
+	res, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	if err != nil {
 		return err
@@ -313,8 +315,8 @@ func saveToFile(body io.ReadCloser, file string) {
 	io.Copy(out, body)
 }
 
-func DownloadTorrentFileToStream(url string) (io.ReadCloser, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func DownloadTorrentFileToStream(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	if err != nil {
 		return nil, err
@@ -1,6 +1,7 @@
 package operations
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -19,7 +21,7 @@ var authCookie []*http.Cookie
 var USER_NAME string
 var USER_PASSWORD string
 
-func authorize() error {
+func authorize(ctx context.Context) error {
 	if authCookie != nil {
 		log.Println("already authorized, skipping")
 		return nil
@@ -41,10 +43,15 @@ func authorize() error {
 	form.Add(passwordFormKey, USER_PASSWORD)
 	form.Add(loginFormKey, loginFormValue)
 
-	res, err := http.PostForm("https://rutracker.org/forum/login.php", form)
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://rutracker.org/forum/login.php", strings.NewReader(form.Encode()))
 	if err != nil {
-		log.Panic(err)
-		return nil
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// cancelled request is not a reason to panic
+		return err
 	}
 
 	if res.StatusCode != 200 {
@@ -57,10 +64,10 @@ func authorize() error {
 	return nil
 }
 
-type createRequest func() (*http.Request, error)
+type createRequest func(ctx context.Context) (*http.Request, error)
 
-func makeRequest(create createRequest) (*http.Response, error) {
-	err := authorize()
+func makeRequest(ctx context.Context, create createRequest) (*http.Response, error) {
+	err := authorize(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +75,7 @@ func makeRequest(create createRequest) (*http.Response, error) {
 	// Declare http client
 	client := &http.Client{}
 
-	req, err := create()
+	req, err := create(ctx)
 	if err != nil {
 		log.Panic(err)
 	}
@@ -79,7 +86,7 @@ func makeRequest(create createRequest) (*http.Response, error) {
 
 	res, err := client.Do(req)
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
 	if res.StatusCode != 200 {
@@ -89,29 +96,29 @@ func makeRequest(create createRequest) (*http.Response, error) {
 	return res, nil
 }
 
-func SearchEverywhere(what string) ([]TorrentItem, error) {
-	return searchItems(searchEverywhere(what))
+func SearchEverywhere(ctx context.Context, what string) ([]TorrentItem, error) {
+	return searchItems(ctx, searchEverywhere(what))
 }
 
-func SearchAudioBooks(what string) ([]TorrentItem, error) {
-	return searchItems(searchAudioBooks(what))
+func SearchAudioBooks(ctx context.Context, what string) ([]TorrentItem, error) {
+	return searchItems(ctx, searchAudioBooks(what))
 }
 
-func SearchMovies(what string) ([]TorrentItem, error) {
-	return searchItems(searchMovies(what))
+func SearchMovies(ctx context.Context, what string) ([]TorrentItem, error) {
+	return searchItems(ctx, searchMovies(what))
 }
 
-func SearchSeries(what string) ([]TorrentItem, error) {
-	return searchItems(searchSeries(what))
+func SearchSeries(ctx context.Context, what string) ([]TorrentItem, error) {
+	return searchItems(ctx, searchSeries(what))
 }
 
-func SearchBooks(what string) ([]TorrentItem, error) {
-	return searchItems(searchTextBooks(what))
+func SearchBooks(ctx context.Context, what string) ([]TorrentItem, error) {
+	return searchItems(ctx, searchTextBooks(what))
 }
 
-func searchItems(uri string) ([]TorrentItem, error) {
-	res, err := makeRequest(func() (*http.Request, error) {
-		return http.NewRequest("GET", uri, nil)
+func searchItems(ctx context.Context, uri string) ([]TorrentItem, error) {
+	res, err := makeRequest(ctx, func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", uri, nil)
 	})
 
 	if err != nil {
@@ -200,9 +207,25 @@ func downloadCall(topicId string) string {
 	return fmt.Sprintf("https://rutracker.org/forum/dl.php?t=%s", topicId)
 }
 
-func DownloadTorrentFile(filepath string, topicId string) error {
-	res, err := makeRequest(func() (*http.Request, error) {
-		return http.NewRequest("GET", downloadCall(topicId), nil)
+var topicUrlRegexp = regexp.MustCompile(`^https?://(?:www\.)?rutracker\.(?:org|net|nl)/forum/viewtopic\.php\?(?:\S*&)?t=([0-9]+)`)
+
+// ParseTopicUrl returns topic id from link to the topic page
+func ParseTopicUrl(link string) (string, bool) {
+	match := topicUrlRegexp.FindStringSubmatch(strings.TrimSpace(link))
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// page of the topic on rutracker
+func TopicUrl(topicId string) string {
+	return fmt.Sprintf("https://rutracker.org/forum/viewtopic.php?t=%s", topicId)
+}
+
+func DownloadTorrentFile(ctx context.Context, filepath string, topicId string) error {
+	res, err := makeRequest(ctx, func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", downloadCall(topicId), nil)
 	})
 
 	if err != nil {
@@ -215,9 +238,9 @@ func DownloadTorrentFile(filepath string, topicId string) error {
 	return err
 }
 
-func DownloadTorrentFileToStream(topicId string) (io.ReadCloser, error) {
-	res, err := makeRequest(func() (*http.Request, error) {
-		return http.NewRequest("GET", downloadCall(topicId), nil)
+func DownloadTorrentFileToStream(ctx context.Context, topicId string) (io.ReadCloser, error) {
+	res, err := makeRequest(ctx, func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", downloadCall(topicId), nil)
 	})
 
 	if err != nil {
@@ -308,3 +331,48 @@ func decodeWindows1251(ba []uint8) []uint8 {
 	out, _ := dec.Bytes(ba)
 	return out
 }
+
+var sizeRegexp = regexp.MustCompile(`^\s*([0-9]+(?:[.,][0-9]+)?)\s*([a-zA-Zа-яА-Я]*)`)
+
+// parse size like "7.3 GB ↓" or "700 мб" to bytes, number without unit is in gigabytes
+func ParseSize(size string) (int64, error) {
+	match := sizeRegexp.FindStringSubmatch(size)
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+
+	value, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	var multiplier float64
+	switch strings.ToLower(match[2]) {
+	case "b", "б":
+		multiplier = 1
+	case "kb", "кб":
+		multiplier = 1 << 10
+	case "mb", "мб":
+		multiplier = 1 << 20
+	case "", "gb", "гб":
+		multiplier = 1 << 30
+	case "tb", "тб":
+		multiplier = 1 << 40
+	default:
+		return 0, fmt.Errorf("invalid size unit %q", match[2])
+	}
+
+	return int64(value * multiplier), nil
+}
+
+// returns items not bigger than maxSize bytes, items with unknown size are skipped
+func FilterBySize(items []TorrentItem, maxSize int64) []TorrentItem {
+	var result []TorrentItem
+	for _, item := range items {
+		size, err := ParseSize(item.Size)
+		if err == nil && size <= maxSize {
+			result = append(result, item)
+		}
+	}
+	return result
+}
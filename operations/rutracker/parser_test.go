@@ -105,3 +105,72 @@ func testItem(actualItem TorrentItem, expectedItem TorrentItem) error {
 // 		t.Log(items[i].Title)
 // 	}
 // }
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"7.5 GB ↓":  int64(7.5 * (1 << 30)),
+		"700 MB":    700 << 20,
+		"10gb":      10 << 30,
+		"1,5 гб":    int64(1.5 * (1 << 30)),
+		"2":         2 << 30,
+		"12 KB":     12 << 10,
+		"0.5 TB":    1 << 39,
+		"26.25 GB ": int64(26.25 * (1 << 30)),
+	}
+	for input, expected := range cases {
+		actual, err := ParseSize(input)
+		if err != nil {
+			t.Fatalf("%s: not expected error %v", input, err)
+		}
+		if actual != expected {
+			t.Fatalf("%s: expected %d, actual %d", input, expected, actual)
+		}
+	}
+}
+
+func TestParseSizeInvalid(t *testing.T) {
+	for _, input := range []string{"", "big", "10 parsecs"} {
+		if _, err := ParseSize(input); err == nil {
+			t.Fatalf("%s: expected error", input)
+		}
+	}
+}
+
+func TestFilterBySize(t *testing.T) {
+	reader, err := os.Open("test_data/item_list.html")
+	if err != nil {
+		t.Error(err)
+	}
+
+	items, _ := parseItemListPage(reader)
+	filtered := FilterBySize(items, 2<<30)
+	if len(filtered) == 0 || len(filtered) >= len(items) {
+		t.Fatalf("expected some items to be filtered, got %d of %d", len(filtered), len(items))
+	}
+	for _, item := range filtered {
+		size, _ := ParseSize(item.Size)
+		if size > 2<<30 {
+			t.Fatalf("item bigger than limit %v", item)
+		}
+	}
+}
+
+func TestParseTopicUrl(t *testing.T) {
+	cases := map[string]string{
+		"https://rutracker.org/forum/viewtopic.php?t=123":        "123",
+		" http://rutracker.org/forum/viewtopic.php?t=6543210\n":  "6543210",
+		"https://rutracker.net/forum/viewtopic.php?start=30&t=7": "7",
+	}
+	for link, expected := range cases {
+		topic, ok := ParseTopicUrl(link)
+		if !ok || topic != expected {
+			t.Fatalf("%q: expected %s, got %s %v", link, expected, topic, ok)
+		}
+	}
+	if _, ok := ParseTopicUrl("https://rutracker.org/forum/viewforum.php?f=93"); ok {
+		t.Fatalf("forum link is not a topic")
+	}
+	if _, ok := ParseTopicUrl("https://example.com/forum/viewtopic.php?t=1"); ok {
+		t.Fatalf("link to other site is not a topic")
+	}
+}
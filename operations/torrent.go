@@ -1,6 +1,7 @@
 package operations
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -20,8 +21,8 @@ type OperationResult struct {
 // define callback function
 type Callback func(result OperationResult)
 
-func DownloadTorrentByPostId(topicId string, destination string, callback Callback) {
-	err := rutracker.DownloadTorrentFile(destination, topicId)
+func DownloadTorrentByPostId(ctx context.Context, topicId string, destination string, callback Callback) {
+	err := rutracker.DownloadTorrentFile(ctx, destination, topicId)
 	if err != nil {
 		fmt.Println("Error download ", err)
 		callback(OperationResult{Text: "cannot download", Err: err})
@@ -31,8 +32,8 @@ func DownloadTorrentByPostId(topicId string, destination string, callback Callba
 	callback(OperationResult{Text: "scheduled"})
 }
 
-func DownloadTorrentByPostIdToStream(topicId string, callback Callback) {
-	stream, err := rutracker.DownloadTorrentFileToStream(topicId)
+func DownloadTorrentByPostIdToStream(ctx context.Context, topicId string, callback Callback) {
+	stream, err := rutracker.DownloadTorrentFileToStream(ctx, topicId)
 	if err != nil {
 		fmt.Println("Error download ", err)
 		callback(OperationResult{Text: "cannot download", Err: err})
@@ -42,8 +43,8 @@ func DownloadTorrentByPostIdToStream(topicId string, callback Callback) {
 	callback(OperationResult{Text: "scheduled", FileStream: stream})
 }
 
-func DownloadJackettTorrentByUri(uri string, destination string, callback Callback) {
-	err := jackett.DownloadTorrentFile(destination, uri)
+func DownloadJackettTorrentByUri(ctx context.Context, uri string, destination string, callback Callback) {
+	err := jackett.DownloadTorrentFile(ctx, destination, uri)
 	if err != nil {
 		fmt.Println("Error download ", err)
 		callback(OperationResult{Text: "cannot download", Err: err})
@@ -53,8 +54,8 @@ func DownloadJackettTorrentByUri(uri string, destination string, callback Callba
 	callback(OperationResult{Text: "scheduled"})
 }
 
-func DownloadJackettTorrentByUriToStream(uri string, callback Callback) {
-	stream, err := jackett.DownloadTorrentFileToStream(uri)
+func DownloadJackettTorrentByUriToStream(ctx context.Context, uri string, callback Callback) {
+	stream, err := jackett.DownloadTorrentFileToStream(ctx, uri)
 	if err != nil {
 		fmt.Println("Error download ", err)
 		callback(OperationResult{Text: "cannot download", Err: err})
@@ -69,22 +70,22 @@ func WatchTorrent(what string, callback Callback) {
 	callback(OperationResult{Text: fmt.Sprintf("watching %s", what)})
 }
 
-func SearchTorrent(what string, where string, callback Callback) {
+func SearchTorrent(ctx context.Context, what string, where string, callback Callback) {
 	var items []rutracker.TorrentItem
 	var err error
 	if where == bot.All {
-		items, err = rutracker.SearchEverywhere(what)
+		items, err = rutracker.SearchEverywhere(ctx, what)
 	} else if where == bot.Audiobooks {
-		items, err = rutracker.SearchAudioBooks(what)
+		items, err = rutracker.SearchAudioBooks(ctx, what)
 	} else if where == bot.Movies {
-		items, err = rutracker.SearchMovies(what)
+		items, err = rutracker.SearchMovies(ctx, what)
 	} else if where == bot.Series {
-		items, err = rutracker.SearchSeries(what)
+		items, err = rutracker.SearchSeries(ctx, what)
 	} else if where == bot.TextBooks {
-		items, err = rutracker.SearchBooks(what)
+		items, err = rutracker.SearchBooks(ctx, what)
 	} else {
 		fmt.Println("Incorrect search destination:" + where)
-		items, err = rutracker.SearchEverywhere(what)
+		items, err = rutracker.SearchEverywhere(ctx, what)
 	}
 
 	if err != nil {
@@ -96,10 +97,10 @@ func SearchTorrent(what string, where string, callback Callback) {
 	callback(OperationResult{Text: "this is what I found", Items: items})
 }
 
-func Download(url string, destination string, callback Callback) {
+func Download(ctx context.Context, url string, destination string, callback Callback) {
 	start := time.Now()
 	fmt.Println("Download...")
-	err := DownloadFile(destination, url)
+	err := DownloadFile(ctx, destination, url)
 	elapsed := time.Now().Sub(start)
 	if err != nil {
 		fmt.Println("Error downloading ", err)
@@ -0,0 +1,55 @@
+package transmission
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hekmon/transmissionrpc/v3"
+)
+
+// fields of torrent details: files, peers, trackers, dates and errors
+var detailFields = []string{"id", "name", "status", "percentDone", "rateDownload", "rateUpload", "eta", "error", "errorString",
+	"sizeWhenDone", "leftUntilDone", "uploadRatio", "downloadDir", "addedDate", "doneDate", "files", "peers", "trackerStats"}
+
+// returns torrent with given id with all fields of details view
+func GetTorrentDetails(ctx context.Context, id int64) (transmissionrpc.Torrent, error) {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return transmissionrpc.Torrent{}, err
+	}
+
+	torrents, err := tbt.TorrentGet(ctx, detailFields, []int64{id})
+	if err != nil {
+		return transmissionrpc.Torrent{}, err
+	}
+	if len(torrents) == 0 {
+		return transmissionrpc.Torrent{}, fmt.Errorf("torrent %d not found", id)
+	}
+
+	return torrents[0], nil
+}
+
+// date like 2024-03-01 15:04, "-" if it is not set, transmission sends 0 for dates which did not happen
+func FormatDate(date *time.Time) string {
+	if date == nil || date.Unix() <= 0 {
+		return "-"
+	}
+	return date.Format("2006-01-02 15:04")
+}
+
+// progress of file from 0 to 1
+func FileProgress(file transmissionrpc.TorrentFile) float64 {
+	if file.Length == 0 {
+		return 1
+	}
+	return float64(file.BytesCompleted) / float64(file.Length)
+}
+
+// remaining time of torrent like 1m30s, unknown if transmission can't estimate it
+func ETAString(t transmissionrpc.Torrent, lang string) string {
+	if t.ETA == nil {
+		return formatETA(lang, -1)
+	}
+	return formatETA(lang, *t.ETA)
+}
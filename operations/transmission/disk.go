@@ -0,0 +1,37 @@
+package transmission
+
+import (
+	"context"
+	"fmt"
+)
+
+// folder where transmission saves torrents by default
+func DownloadDir(ctx context.Context) (string, error) {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	session, err := tbt.SessionArgumentsGet(ctx, []string{"download-dir"})
+	if err != nil {
+		return "", err
+	}
+	if session.DownloadDir == nil {
+		return "", fmt.Errorf("transmission has no download dir")
+	}
+	return *session.DownloadDir, nil
+}
+
+// free and total bytes of the folder on transmission server, total is 0 if transmission is older than 4.0
+func FreeSpace(ctx context.Context, path string) (int64, int64, error) {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	free, total, err := tbt.FreeSpace(ctx, path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(free.Byte()), int64(total.Byte()), nil
+}
@@ -0,0 +1,88 @@
+package transmission
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hekmon/transmissionrpc/v3"
+)
+
+// priorities of files, as transmission sends them in fileStats
+const (
+	PriorityLow    int64 = -1
+	PriorityNormal int64 = 0
+	PriorityHigh   int64 = 1
+)
+
+// fields needed to choose files of torrent
+var fileFields = []string{"id", "name", "files", "fileStats"}
+
+// returns torrent with its files and their wanted flags and priorities,
+// files of magnet are empty until transmission gets its metadata
+func GetTorrentFiles(ctx context.Context, id int64) (transmissionrpc.Torrent, error) {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return transmissionrpc.Torrent{}, err
+	}
+
+	torrents, err := tbt.TorrentGet(ctx, fileFields, []int64{id})
+	if err != nil {
+		return transmissionrpc.Torrent{}, err
+	}
+	if len(torrents) == 0 {
+		return transmissionrpc.Torrent{}, fmt.Errorf("torrent %d not found", id)
+	}
+
+	return torrents[0], nil
+}
+
+// download or skip files with given indexes
+func SetFilesWanted(ctx context.Context, id int64, indexes []int64, wanted bool) error {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload := transmissionrpc.TorrentSetPayload{IDs: []int64{id}}
+	if wanted {
+		payload.FilesWanted = indexes
+	} else {
+		payload.FilesUnwanted = indexes
+	}
+	return tbt.TorrentSet(ctx, payload)
+}
+
+// set priority of files with given indexes, one of PriorityLow, PriorityNormal and PriorityHigh
+func SetFilesPriority(ctx context.Context, id int64, indexes []int64, priority int64) error {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload := transmissionrpc.TorrentSetPayload{IDs: []int64{id}}
+	switch priority {
+	case PriorityLow:
+		payload.PriorityLow = indexes
+	case PriorityHigh:
+		payload.PriorityHigh = indexes
+	case PriorityNormal:
+		payload.PriorityNormal = indexes
+	default:
+		return fmt.Errorf("unknown priority %d", priority)
+	}
+	return tbt.TorrentSet(ctx, payload)
+}
+
+// count and size of files which are downloaded
+func WantedFiles(t transmissionrpc.Torrent) (int, int64) {
+	count := 0
+	var size int64
+	for i, file := range t.Files {
+		if i < len(t.FileStats) && !t.FileStats[i].Wanted {
+			continue
+		}
+		count++
+		size += file.Length
+	}
+	return count, size
+}
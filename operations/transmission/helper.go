@@ -1,6 +1,7 @@
 package transmission
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -8,6 +9,9 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/telegram-command-reader/i18n"
 )
 
 type WatchedFolder struct {
@@ -30,8 +34,8 @@ func New(path string) WatchedFolder {
 	return WatchedFolder{path, fileMap, allFiles}
 }
 
-// return new file or error
-func (folder WatchedFolder) WaitForNewFileWithRetry(seconds int) (string, error) {
+// return new file or error, waiting stops when ctx is done
+func (folder WatchedFolder) WaitForNewFileWithRetry(ctx context.Context, seconds int) (string, error) {
 	maxRetry := seconds
 	for i := 0; i < maxRetry; i++ {
 		list := folder.ReadAllFilesInFolder()
@@ -47,7 +51,11 @@ func (folder WatchedFolder) WaitForNewFileWithRetry(seconds int) (string, error)
 		}
 
 		// sleep for a second
-		time.Sleep(time.Second)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Second):
+		}
 	}
 
 	return "", fmt.Errorf("no new file found")
@@ -110,8 +118,8 @@ func readAllFilesInFolder(path string) []string {
 	return result
 }
 
-func GetAllTorrentsAsString() (string, error) {
-	torrents, err := GetAllTorrents()
+func GetAllTorrentsAsString(ctx context.Context) (string, error) {
+	torrents, err := GetAllTorrents(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get torrents: %w", err)
 	}
@@ -141,3 +149,78 @@ func GetAllTorrentsAsString() (string, error) {
 
 	return result.String(), nil
 }
+
+// format size like 1.5 GB
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	units := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+// returns bar like [█████░░░░░] for percent from 0 to 1
+func progressBar(percent float64, width int) string {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 1 {
+		percent = 1
+	}
+	filled := int(percent * float64(width))
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
+
+// format remaining time, transmission sends negative eta when it is unknown
+func formatETA(lang string, eta int64) string {
+	if eta < 0 {
+		return i18n.T(lang, i18n.ETAUnknown)
+	}
+	return (time.Duration(eta) * time.Second).String()
+}
+
+// multiline progress of torrent with bar, speed and ETA in language lang
+func ProgressString(t transmissionrpc.Torrent, lang string) string {
+	var result strings.Builder
+	if t.Name != nil {
+		result.WriteString(*t.Name + "\n")
+	}
+
+	percent := 0.0
+	if t.PercentDone != nil {
+		percent = *t.PercentDone
+	}
+	result.WriteString(fmt.Sprintf("%s %.1f%%", progressBar(percent, 10), percent*100))
+	if t.Status != nil {
+		result.WriteString(", " + statusName(*t.Status, lang))
+	}
+	result.WriteString("\n")
+
+	if t.ErrorString != nil && *t.ErrorString != "" {
+		result.WriteString(i18n.T(lang, i18n.ProgressError, *t.ErrorString))
+	}
+
+	if percent < 1 {
+		var down, up, eta int64
+		if t.RateDownload != nil {
+			down = *t.RateDownload
+		}
+		if t.RateUpload != nil {
+			up = *t.RateUpload
+		}
+		eta = -1
+		if t.ETA != nil {
+			eta = *t.ETA
+		}
+		result.WriteString(i18n.T(lang, i18n.ProgressSpeed, FormatBytes(down), FormatBytes(up), formatETA(lang, eta)))
+	}
+
+	return result.String()
+}
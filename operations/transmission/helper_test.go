@@ -0,0 +1,226 @@
+package transmission
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/hekmon/cunits/v2"
+	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/telegram-command-reader/i18n"
+)
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[int64]string{
+		0:             "0 B",
+		1023:          "1023 B",
+		1536:          "1.5 KB",
+		10 << 20:      "10.0 MB",
+		3 << 30:       "3.0 GB",
+		5 << 40:       "5.0 TB",
+		(1 << 50) * 2: "2048.0 TB",
+	}
+	for input, expected := range cases {
+		if actual := FormatBytes(input); actual != expected {
+			t.Fatalf("%d: expected %s, actual %s", input, expected, actual)
+		}
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	if actual := progressBar(0.5, 10); actual != "[█████░░░░░]" {
+		t.Fatalf("not expected %s", actual)
+	}
+	if actual := progressBar(1.5, 4); actual != "[████]" {
+		t.Fatalf("not expected %s", actual)
+	}
+}
+
+func TestProgressString(t *testing.T) {
+	name := "Matrix"
+	percent := 0.25
+	down := int64(2 << 20)
+	up := int64(0)
+	eta := int64(90)
+	status := transmissionrpc.TorrentStatusDownload
+	actual := ProgressString(transmissionrpc.Torrent{Name: &name, PercentDone: &percent, RateDownload: &down, RateUpload: &up, ETA: &eta, Status: &status}, i18n.English)
+	expected := "Matrix\n[██░░░░░░░░] 25.0%, downloading\n↓ 2.0 MB/s ↑ 0 B/s, ETA 1m30s"
+	if actual != expected {
+		t.Fatalf("expected %q, actual %q", expected, actual)
+	}
+}
+
+func TestProgressStringInRussian(t *testing.T) {
+	name := "Matrix"
+	percent := 0.25
+	status := transmissionrpc.TorrentStatusDownload
+	errorString := "tracker error"
+	actual := ProgressString(transmissionrpc.Torrent{Name: &name, PercentDone: &percent, Status: &status, ErrorString: &errorString}, i18n.Russian)
+	expected := "Matrix\n[██░░░░░░░░] 25.0%, загружается\nОшибка: tracker error\n↓ 0 B/s ↑ 0 B/s, осталось неизвестно"
+	if actual != expected {
+		t.Fatalf("expected %q, actual %q", expected, actual)
+	}
+}
+
+func TestProgressStringFinished(t *testing.T) {
+	name := "Matrix"
+	percent := 1.0
+	actual := ProgressString(transmissionrpc.Torrent{Name: &name, PercentDone: &percent}, i18n.English)
+	if strings.Contains(actual, "ETA") || !strings.Contains(actual, "100.0%") {
+		t.Fatalf("not expected %q", actual)
+	}
+}
+
+func TestParseMagnet(t *testing.T) {
+	uri := "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=Matrix+1999&tr=udp%3A%2F%2Ftracker.opentrackr.org%3A1337%2Fannounce&tr=http%3A%2F%2Fbt.t-ru.org%2Fann&tr=udp%3A%2F%2Ftracker.opentrackr.org%3A1337%2Fannounce"
+	magnet, err := ParseMagnet(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if magnet.Name != "Matrix 1999" || magnet.InfoHash != "c12fe1c06bba254a9dc9f519b335aa7c1367a88a" || len(magnet.Trackers) != 3 {
+		t.Fatalf("not expected %+v", magnet)
+	}
+	hosts := magnet.TrackerHosts()
+	if strings.Join(hosts, ",") != "tracker.opentrackr.org,bt.t-ru.org" {
+		t.Fatalf("not expected hosts %v", hosts)
+	}
+
+	if _, err := ParseMagnet("magnet:?dn=no+hash"); err == nil {
+		t.Fatalf("expected error for magnet without infohash")
+	}
+}
+
+func TestParseTorrent(t *testing.T) {
+	private := true
+	info := metainfo.Info{
+		Name:        "Matrix",
+		PieceLength: 256 << 10,
+		Pieces:      make([]byte, 20),
+		Private:     &private,
+		Files: []metainfo.FileInfo{
+			{Path: []string{"sample.mkv"}, Length: 100},
+			{Path: []string{"Matrix.mkv"}, Length: 1 << 30},
+			{Path: []string{"subs", "en.srt"}, Length: 10},
+		},
+	}
+	infoBytes, err := bencode.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mi := metainfo.MetaInfo{
+		InfoBytes:    infoBytes,
+		Announce:     "http://bt.t-ru.org/ann",
+		AnnounceList: metainfo.AnnounceList{{"http://bt.t-ru.org/ann"}, {"udp://tracker.opentrackr.org:1337/announce"}},
+	}
+	var buffer bytes.Buffer
+	if err := mi.Write(&buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	torrent, err := ParseTorrent(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent.Name != "Matrix" || torrent.TotalSize != 1<<30+110 || torrent.PieceLength != 256<<10 || !torrent.Private {
+		t.Fatalf("not expected %+v", torrent)
+	}
+	if torrent.InfoHash != mi.HashInfoBytes().HexString() {
+		t.Fatalf("not expected infohash %s", torrent.InfoHash)
+	}
+	if len(torrent.Files) != 3 || torrent.Files[0].Path != "Matrix.mkv" || torrent.Files[2].Path != "subs/en.srt" {
+		t.Fatalf("files are not sorted by size %+v", torrent.Files)
+	}
+	if hosts := torrent.TrackerHosts(); strings.Join(hosts, ",") != "bt.t-ru.org,tracker.opentrackr.org" {
+		t.Fatalf("not expected hosts %v", hosts)
+	}
+
+	if _, err := ParseTorrent(strings.NewReader("<html>not a torrent</html>")); err == nil {
+		t.Fatalf("expected error for not a torrent")
+	}
+}
+
+func listTorrent(name string, percent float64, size int64, added int64, status transmissionrpc.TorrentStatus) transmissionrpc.Torrent {
+	bits := cunits.ImportInByte(float64(size))
+	date := time.Unix(added, 0)
+	return transmissionrpc.Torrent{Name: &name, PercentDone: &percent, SizeWhenDone: &bits, AddedDate: &date, Status: &status}
+}
+
+func TestFilterAndSortTorrents(t *testing.T) {
+	torrents := []transmissionrpc.Torrent{
+		listTorrent("b", 0.5, 300, 1, transmissionrpc.TorrentStatusDownload),
+		listTorrent("seeding", 1, 100, 2, transmissionrpc.TorrentStatusSeed),
+		listTorrent("A", 0.9, 200, 3, transmissionrpc.TorrentStatusDownloadWait),
+		listTorrent("c", 0.1, 100, 2, transmissionrpc.TorrentStatusStopped),
+	}
+	finished := FilterTorrents(torrents, ViewFinished)
+	if len(finished) != 1 || *finished[0].Name != "seeding" {
+		t.Fatalf("not expected finished %v", finished)
+	}
+
+	downloading := FilterTorrents(torrents, ViewDownloading)
+	cases := map[string]string{SortName: "A b c", SortProgress: "A b c", SortSize: "b A c", SortAdded: "A c b", "": "A b c"}
+	for sortBy, expected := range cases {
+		SortTorrents(downloading, sortBy)
+		var names []string
+		for _, torrent := range downloading {
+			names = append(names, *torrent.Name)
+		}
+		if actual := strings.Join(names, " "); actual != expected {
+			t.Errorf("sort by %q: expected %q, actual %q", sortBy, expected, actual)
+		}
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	torrent := listTorrent("a", 0.5, 1, 1, transmissionrpc.TorrentStatusDownload)
+	if actual := StatusString(torrent, i18n.English); actual != "stalled" {
+		t.Fatalf("expected stalled, got %s", actual)
+	}
+	rate := int64(10)
+	torrent.RateDownload = &rate
+	if actual := StatusString(torrent, i18n.English); actual != "downloading" {
+		t.Fatalf("expected downloading, got %s", actual)
+	}
+}
+
+func TestStatusCounts(t *testing.T) {
+	rate := int64(10)
+	downloading := listTorrent("a", 0.5, 1, 1, transmissionrpc.TorrentStatusDownload)
+	downloading.RateDownload = &rate
+	torrents := []transmissionrpc.Torrent{
+		listTorrent("b", 0.5, 1, 1, transmissionrpc.TorrentStatusStopped),
+		downloading,
+		listTorrent("c", 1, 1, 1, transmissionrpc.TorrentStatusStopped),
+	}
+	if actual := StatusCounts(torrents, i18n.English); actual != "downloading: 1, stopped: 2" {
+		t.Fatalf("not expected %q", actual)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	zero := time.Unix(0, 0)
+	if actual := FormatDate(&zero); actual != "-" {
+		t.Fatalf("expected -, got %s", actual)
+	}
+	if actual := FormatDate(nil); actual != "-" {
+		t.Fatalf("expected -, got %s", actual)
+	}
+	date := time.Date(2024, 3, 1, 15, 4, 0, 0, time.Local)
+	if actual := FormatDate(&date); actual != "2024-03-01 15:04" {
+		t.Fatalf("not expected %s", actual)
+	}
+}
+
+func TestWantedFiles(t *testing.T) {
+	torrent := transmissionrpc.Torrent{
+		Files:     []transmissionrpc.TorrentFile{{Name: "e01.mkv", Length: 100}, {Name: "e02.mkv", Length: 200}, {Name: "sample.mkv", Length: 5}},
+		FileStats: []transmissionrpc.TorrentFileStat{{Wanted: true}, {Wanted: true}, {Wanted: false}},
+	}
+	count, size := WantedFiles(torrent)
+	if count != 2 || size != 300 {
+		t.Fatalf("expected 2 files of 300 bytes, got %d of %d", count, size)
+	}
+}
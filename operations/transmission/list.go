@@ -0,0 +1,186 @@
+package transmission
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/telegram-command-reader/i18n"
+)
+
+// which torrents are listed
+type TorrentView int
+
+const (
+	ViewDownloading TorrentView = iota // not completed: downloading, queued, checking, stalled or paused
+	ViewFinished                       // completed or seeding
+)
+
+// orders of torrent list, values are passed in buttons
+const (
+	SortName     = "name"
+	SortProgress = "progress"
+	SortSize     = "size"
+	SortAdded    = "added"
+)
+
+// fields needed to show torrent lists
+var listFields = []string{"id", "name", "status", "percentDone", "rateDownload", "rateUpload", "eta", "error", "errorString", "sizeWhenDone", "addedDate", "uploadRatio"}
+
+// returns torrents of the view in given order
+func ListTorrents(ctx context.Context, view TorrentView, sortBy string) ([]transmissionrpc.Torrent, error) {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	torrents, err := tbt.TorrentGet(ctx, listFields, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	torrents = FilterTorrents(torrents, view)
+	SortTorrents(torrents, sortBy)
+	return torrents, nil
+}
+
+// torrents which belong to the view
+func FilterTorrents(torrents []transmissionrpc.Torrent, view TorrentView) []transmissionrpc.Torrent {
+	var result []transmissionrpc.Torrent
+	for _, t := range torrents {
+		if IsFinished(t) == (view == ViewFinished) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// sort torrents by name, by progress or size from biggest, or by date added from newest
+func SortTorrents(torrents []transmissionrpc.Torrent, sortBy string) {
+	sort.SliceStable(torrents, func(i, j int) bool {
+		a, b := torrents[i], torrents[j]
+		switch sortBy {
+		case SortProgress:
+			return percentDone(a) > percentDone(b)
+		case SortSize:
+			return sizeWhenDone(a) > sizeWhenDone(b)
+		case SortAdded:
+			if a.AddedDate == nil || b.AddedDate == nil {
+				return a.AddedDate != nil
+			}
+			return a.AddedDate.After(*b.AddedDate)
+		}
+		return strings.ToLower(torrentName(a)) < strings.ToLower(torrentName(b))
+	})
+}
+
+// true if all wanted files are downloaded
+func IsFinished(t transmissionrpc.Torrent) bool {
+	return percentDone(t) >= 1
+}
+
+// true if torrent is downloading but nothing is received
+func IsStalled(t transmissionrpc.Torrent) bool {
+	return t.Status != nil && *t.Status == transmissionrpc.TorrentStatusDownload &&
+		(t.RateDownload == nil || *t.RateDownload == 0)
+}
+
+var statusKeys = map[transmissionrpc.TorrentStatus]i18n.Key{
+	transmissionrpc.TorrentStatusStopped:      i18n.StatusStopped,
+	transmissionrpc.TorrentStatusCheckWait:    i18n.StatusCheckWait,
+	transmissionrpc.TorrentStatusCheck:        i18n.StatusCheck,
+	transmissionrpc.TorrentStatusDownloadWait: i18n.StatusDownloadWait,
+	transmissionrpc.TorrentStatusDownload:     i18n.StatusDownload,
+	transmissionrpc.TorrentStatusSeedWait:     i18n.StatusSeedWait,
+	transmissionrpc.TorrentStatusSeed:         i18n.StatusSeed,
+	transmissionrpc.TorrentStatusIsolated:     i18n.StatusIsolated,
+}
+
+// name of transmission status in language lang
+func statusName(status transmissionrpc.TorrentStatus, lang string) string {
+	key, ok := statusKeys[status]
+	if !ok {
+		key = i18n.StatusUnknown
+	}
+	return i18n.T(lang, key)
+}
+
+// status of torrent for lists, like downloading, queued to download or stalled
+func StatusString(t transmissionrpc.Torrent, lang string) string {
+	if IsStalled(t) {
+		return i18n.T(lang, i18n.StatusStalled)
+	}
+	if t.Status == nil {
+		return i18n.T(lang, i18n.StatusUnknown)
+	}
+	return statusName(*t.Status, lang)
+}
+
+func percentDone(t transmissionrpc.Torrent) float64 {
+	if t.PercentDone == nil {
+		return 0
+	}
+	return *t.PercentDone
+}
+
+// size of wanted files in bytes
+func sizeWhenDone(t transmissionrpc.Torrent) int64 {
+	if t.SizeWhenDone == nil {
+		return 0
+	}
+	return int64(t.SizeWhenDone.Byte())
+}
+
+func torrentName(t transmissionrpc.Torrent) string {
+	if t.Name == nil {
+		return ""
+	}
+	return *t.Name
+}
+
+// size of wanted files like 1.5 GB
+func SizeString(t transmissionrpc.Torrent) string {
+	return FormatBytes(sizeWhenDone(t))
+}
+
+// download speed and eta like "↓ 2.0 MB/s, ETA 1m30s"
+func SpeedString(t transmissionrpc.Torrent, lang string) string {
+	var down int64
+	if t.RateDownload != nil {
+		down = *t.RateDownload
+	}
+	eta := int64(-1)
+	if t.ETA != nil {
+		eta = *t.ETA
+	}
+	return i18n.T(lang, i18n.DownloadSpeed, FormatBytes(down), formatETA(lang, eta))
+}
+
+// upload ratio like 0.53, transmission sends negative ratio when nothing is uploaded
+func RatioString(t transmissionrpc.Torrent) string {
+	if t.UploadRatio == nil || *t.UploadRatio < 0 {
+		return "0.00"
+	}
+	return strconv.FormatFloat(*t.UploadRatio, 'f', 2, 64)
+}
+
+// count of torrents in every status like "stopped: 3, seeding: 2", statuses are in order of names
+func StatusCounts(torrents []transmissionrpc.Torrent, lang string) string {
+	counts := make(map[string]int)
+	for _, t := range torrents {
+		counts[StatusString(t, lang)]++
+	}
+	var statuses []string
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	var result []string
+	for _, status := range statuses {
+		result = append(result, i18n.T(lang, i18n.StatusCount, status, counts[status]))
+	}
+	return strings.Join(result, ", ")
+}
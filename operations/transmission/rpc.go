@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strconv"
 
 	"github.com/hekmon/transmissionrpc/v3"
 )
@@ -18,12 +19,12 @@ var dynamicPort int
 
 // http://127.0.0.1:9091/transmission/rpc
 func getTransmissionUriString(port int) string {
-	return fmt.Sprintf("http://%s:%d/transmission/rpc", RPC_URI, port)
+	return fmt.Sprintf("http://%s/transmission/rpc", net.JoinHostPort(RPC_URI, strconv.Itoa(port)))
 }
 
-func getClient() (*transmissionrpc.Client, error) {
+func getClient(ctx context.Context) (*transmissionrpc.Client, error) {
 	if client != nil {
-		ok, _ := checkRPCConnection(client)
+		ok, _ := checkRPCConnection(ctx, client)
 		if ok {
 			return client, nil
 		} else {
@@ -37,10 +38,11 @@ func getClient() (*transmissionrpc.Client, error) {
 		for port := RPC_PORT_FROM; port <= RPC_PORT_TO; port++ {
 			endpoint := getTransmissionUriString(port)
 			fmt.Println("RPC checking uri ", endpoint)
-			conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", RPC_URI, port))
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(RPC_URI, strconv.Itoa(port)))
 			if err == nil {
 				conn.Close()
-				client, err = makeClient(endpoint)
+				client, err = makeClient(ctx, endpoint)
 				if err != nil {
 					continue
 				} else {
@@ -57,7 +59,7 @@ func getClient() (*transmissionrpc.Client, error) {
 }
 
 // uri in format http://127.0.0.1:9091/transmission/rpc
-func makeClient(uri string) (*transmissionrpc.Client, error) {
+func makeClient(ctx context.Context, uri string) (*transmissionrpc.Client, error) {
 	endpoint, err := url.Parse(uri)
 	if err != nil {
 		return nil, err
@@ -68,7 +70,7 @@ func makeClient(uri string) (*transmissionrpc.Client, error) {
 		return nil, err
 	}
 
-	ok, err := checkRPCConnection(tbt)
+	ok, err := checkRPCConnection(ctx, tbt)
 	if !ok {
 		return nil, err
 	}
@@ -76,13 +78,13 @@ func makeClient(uri string) (*transmissionrpc.Client, error) {
 	return tbt, nil
 }
 
-func CheckRPCConnection() (bool, error) {
-	tbt, err := getClient()
+func CheckRPCConnection(ctx context.Context) (bool, error) {
+	tbt, err := getClient(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	ok, err := checkRPCConnection(tbt)
+	ok, err := checkRPCConnection(ctx, tbt)
 
 	if ok {
 		client = tbt
@@ -92,8 +94,8 @@ func CheckRPCConnection() (bool, error) {
 	}
 }
 
-func checkRPCConnection(clientLocal *transmissionrpc.Client) (bool, error) {
-	ok, serverVersion, serverMinimumVersion, err := clientLocal.RPCVersion(context.Background())
+func checkRPCConnection(ctx context.Context, clientLocal *transmissionrpc.Client) (bool, error) {
+	ok, serverVersion, serverMinimumVersion, err := clientLocal.RPCVersion(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -109,13 +111,13 @@ func checkRPCConnection(clientLocal *transmissionrpc.Client) (bool, error) {
 	return true, nil
 }
 
-func GetAllTorrents() ([]transmissionrpc.Torrent, error) {
-	tbt, err := getClient()
+func GetAllTorrents(ctx context.Context) ([]transmissionrpc.Torrent, error) {
+	tbt, err := getClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	torrents, err := tbt.TorrentGetAll(context.Background())
+	torrents, err := tbt.TorrentGetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -123,13 +125,14 @@ func GetAllTorrents() ([]transmissionrpc.Torrent, error) {
 	return torrents, nil
 }
 
-func RemoveTorrent(id int64) (bool, error) {
-	tbt, err := getClient()
+// remove torrent from transmission, downloaded files are removed too if deleteData is set
+func RemoveTorrent(ctx context.Context, id int64, deleteData bool) (bool, error) {
+	tbt, err := getClient(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	err = tbt.TorrentRemove(context.Background(), transmissionrpc.TorrentRemovePayload{IDs: []int64{id}})
+	err = tbt.TorrentRemove(ctx, transmissionrpc.TorrentRemovePayload{IDs: []int64{id}, DeleteLocalData: deleteData})
 	if err != nil {
 		return false, err
 	}
@@ -137,23 +140,107 @@ func RemoveTorrent(id int64) (bool, error) {
 	return true, nil
 }
 
-func AddTorrent(magnet string) (bool, error) {
-	tbt, err := getClient()
+// add torrent by magnet link, returns id of added torrent
+func AddTorrent(ctx context.Context, magnet string) (int64, error) {
+	tbt, err := getClient(ctx)
 	if err != nil {
-		return false, err
+		return 0, err
 	}
 
-	torrent, err := tbt.TorrentAdd(context.Background(), transmissionrpc.TorrentAddPayload{
+	torrent, err := tbt.TorrentAdd(ctx, transmissionrpc.TorrentAddPayload{
 		Filename: &magnet,
 	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		return false, err
+		return 0, err
 	} else {
 		// Only 3 fields will be returned/set in the Torrent struct
 		fmt.Println(*torrent.ID)
 		fmt.Println(*torrent.Name)
 		fmt.Println(*torrent.HashString)
-		return true, nil
+		return *torrent.ID, nil
+	}
+}
+
+// fields needed to show download progress
+var progressFields = []string{"id", "name", "status", "percentDone", "rateDownload", "rateUpload", "eta", "error", "errorString", "sizeWhenDone", "leftUntilDone"}
+
+// returns progress of torrent with given id
+func GetTorrent(ctx context.Context, id int64) (transmissionrpc.Torrent, error) {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return transmissionrpc.Torrent{}, err
+	}
+
+	torrents, err := tbt.TorrentGet(ctx, progressFields, []int64{id})
+	if err != nil {
+		return transmissionrpc.Torrent{}, err
+	}
+	if len(torrents) == 0 {
+		return transmissionrpc.Torrent{}, fmt.Errorf("torrent %d not found", id)
+	}
+
+	return torrents[0], nil
+}
+
+// returns torrent by name, name is the title from .torrent file
+func FindTorrentByName(ctx context.Context, name string) (transmissionrpc.Torrent, error) {
+	torrents, err := GetAllTorrents(ctx)
+	if err != nil {
+		return transmissionrpc.Torrent{}, err
+	}
+
+	for _, t := range torrents {
+		if t.Name != nil && *t.Name == name {
+			return t, nil
+		}
 	}
+
+	return transmissionrpc.Torrent{}, fmt.Errorf("torrent %s not found", name)
+}
+
+// actions on torrents, names are used in commands and buttons
+const (
+	ActionStop       = "stop"
+	ActionStart      = "start"
+	ActionStartNow   = "startnow" // start without waiting in the queue
+	ActionVerify     = "verify"
+	ActionReannounce = "reannounce"
+)
+
+// run action on torrents with given ids, on all torrents if ids is empty,
+// returns torrents after the action to confirm their status
+func ControlTorrents(ctx context.Context, action string, ids []int64) ([]transmissionrpc.Torrent, error) {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// empty list means all torrents for transmission
+	switch action {
+	case ActionStop:
+		err = tbt.TorrentStopIDs(ctx, ids)
+	case ActionStart:
+		err = tbt.TorrentStartIDs(ctx, ids)
+	case ActionStartNow:
+		err = tbt.TorrentStartNowIDs(ctx, ids)
+	case ActionVerify:
+		err = tbt.TorrentVerifyIDs(ctx, ids)
+	case ActionReannounce:
+		err = tbt.TorrentReannounceIDs(ctx, ids)
+	default:
+		err = fmt.Errorf("unknown action %s", action)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	torrents, err := tbt.TorrentGet(ctx, progressFields, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 && len(torrents) == 0 {
+		return nil, fmt.Errorf("torrent %d not found", ids[0])
+	}
+	return torrents, nil
 }
@@ -0,0 +1,203 @@
+package transmission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SpeedSchedule sets limits every day from From to To, times are minutes after midnight,
+// To is before From if the schedule lasts over midnight
+type SpeedSchedule struct {
+	From   int         `json:"from"`
+	To     int         `json:"to"`
+	Limits SpeedLimits `json:"limits"`
+}
+
+var ErrNoSchedule = errors.New("no such schedule")
+
+// schedule like 18:00-23:00 2MB or 23:00-7:00 5MB 1MB, the second speed limits upload
+var scheduleRegexp = regexp.MustCompile(`^(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})\s+(\S+)(?:\s+(\S+))?$`)
+
+// parse schedule of download and optional upload limits, upload is not limited if it is missing
+func ParseSchedule(text string) (SpeedSchedule, error) {
+	match := scheduleRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return SpeedSchedule{}, fmt.Errorf("invalid schedule %q", text)
+	}
+
+	var schedule SpeedSchedule
+	var err error
+	if schedule.From, err = parseMinutes(match[1], match[2]); err != nil {
+		return SpeedSchedule{}, err
+	}
+	if schedule.To, err = parseMinutes(match[3], match[4]); err != nil {
+		return SpeedSchedule{}, err
+	}
+	if schedule.From == schedule.To {
+		return SpeedSchedule{}, fmt.Errorf("schedule %q has the same start and end", text)
+	}
+
+	if schedule.Limits.Down, schedule.Limits.DownEnabled, err = ParseSpeedLimit(match[5]); err != nil {
+		return SpeedSchedule{}, err
+	}
+	if match[6] != "" {
+		if schedule.Limits.Up, schedule.Limits.UpEnabled, err = ParseSpeedLimit(match[6]); err != nil {
+			return SpeedSchedule{}, err
+		}
+	}
+	return schedule, nil
+}
+
+func parseMinutes(hours string, minutes string) (int, error) {
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(minutes)
+	if h > 23 || m > 59 {
+		return 0, fmt.Errorf("invalid time %s:%s", hours, minutes)
+	}
+	return h*60 + m, nil
+}
+
+// true if local time now is inside the schedule
+func (schedule SpeedSchedule) Active(now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+	if schedule.From < schedule.To {
+		return minute >= schedule.From && minute < schedule.To
+	}
+	return minute >= schedule.From || minute < schedule.To
+}
+
+// hours of the schedule like 18:00-23:00
+func (schedule SpeedSchedule) Hours() string {
+	return fmt.Sprintf("%d:%02d-%d:%02d", schedule.From/60, schedule.From%60, schedule.To/60, schedule.To%60)
+}
+
+// schedules are saved with limits which they replaced, so limits are restored after restart too
+type scheduleRegistry struct {
+	mutex     sync.Mutex
+	applying  sync.Mutex // only one ApplySchedules changes transmission at once
+	path      string
+	Schedules []SpeedSchedule `json:"schedules"`
+	Applied   *SpeedSchedule  `json:"applied,omitempty"` // schedule which limits are set now
+	Saved     *SpeedLimits    `json:"saved,omitempty"`   // limits before the schedule, they are set back when it ends
+}
+
+var schedules = &scheduleRegistry{}
+
+// load speed schedules, missing file is not an error
+func LoadSchedules(path string) error {
+	schedules.mutex.Lock()
+	defer schedules.mutex.Unlock()
+	schedules.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, schedules)
+}
+
+// speed schedules in order they were added
+func Schedules() []SpeedSchedule {
+	schedules.mutex.Lock()
+	defer schedules.mutex.Unlock()
+	return append([]SpeedSchedule(nil), schedules.Schedules...)
+}
+
+// add schedule and save it to file, it is applied on next ApplySchedules
+func AddSchedule(schedule SpeedSchedule) error {
+	schedules.mutex.Lock()
+	defer schedules.mutex.Unlock()
+	schedules.Schedules = append(schedules.Schedules, schedule)
+	return schedules.save()
+}
+
+// remove schedule with index from 0, limits before it are set back on next ApplySchedules
+func RemoveSchedule(index int) (SpeedSchedule, error) {
+	schedules.mutex.Lock()
+	defer schedules.mutex.Unlock()
+	if index < 0 || index >= len(schedules.Schedules) {
+		return SpeedSchedule{}, ErrNoSchedule
+	}
+	removed := schedules.Schedules[index]
+	schedules.Schedules = append(schedules.Schedules[:index], schedules.Schedules[index+1:]...)
+	return removed, schedules.save()
+}
+
+// set limits of the first schedule active now, or set back limits which were before schedules,
+// transmission is changed only when active schedule changes, so limits set by hand are kept until then
+func ApplySchedules(ctx context.Context, now time.Time) error {
+	// rpc calls are made without mutex, so schedules can be listed and changed meanwhile
+	schedules.applying.Lock()
+	defer schedules.applying.Unlock()
+
+	schedules.mutex.Lock()
+	var active *SpeedSchedule
+	for i := range schedules.Schedules {
+		if schedules.Schedules[i].Active(now) {
+			schedule := schedules.Schedules[i]
+			active = &schedule
+			break
+		}
+	}
+	applied, saved := schedules.Applied, schedules.Saved
+	schedules.mutex.Unlock()
+
+	if (active == nil && applied == nil) || (active != nil && applied != nil && *active == *applied) {
+		return nil
+	}
+
+	if active == nil {
+		if saved != nil {
+			if err := SetSpeedLimits(ctx, *saved); err != nil {
+				return err
+			}
+		}
+		return schedules.setApplied(nil, nil)
+	}
+
+	if saved == nil {
+		settings, err := GetSpeedSettings(ctx)
+		if err != nil {
+			return err
+		}
+		saved = &settings.Limits
+	}
+	if err := SetSpeedLimits(ctx, active.Limits); err != nil {
+		return err
+	}
+	return schedules.setApplied(active, saved)
+}
+
+// remember applied schedule with limits before it and save them to file
+func (registry *scheduleRegistry) setApplied(applied *SpeedSchedule, saved *SpeedLimits) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.Applied = applied
+	registry.Saved = saved
+	return registry.save()
+}
+
+// write schedules to file, mutex must be locked
+func (registry *scheduleRegistry) save() error {
+	if registry.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(registry)
+	if err != nil {
+		return err
+	}
+	tmpPath := registry.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, registry.path)
+}
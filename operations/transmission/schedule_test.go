@@ -0,0 +1,113 @@
+package transmission
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSpeedLimit(t *testing.T) {
+	cases := map[string]int64{
+		"500":      500,
+		"500k":     500,
+		"500 KB":   500,
+		"2MB":      2000,
+		"1,5 mb/s": 1500,
+	}
+	for input, expected := range cases {
+		limit, enabled, err := ParseSpeedLimit(input)
+		if err != nil || !enabled || limit != expected {
+			t.Fatalf("%s: expected %d, actual %d %v %v", input, expected, limit, enabled, err)
+		}
+	}
+	if _, enabled, err := ParseSpeedLimit("off"); err != nil || enabled {
+		t.Fatalf("off must disable limit, %v %v", enabled, err)
+	}
+	for _, input := range []string{"fast", "2GB", "0.1k"} {
+		if _, _, err := ParseSpeedLimit(input); err == nil {
+			t.Fatalf("%s must be invalid", input)
+		}
+	}
+}
+
+func TestSpeedLimitString(t *testing.T) {
+	if actual := SpeedLimitString(500); actual != "500 kB/s" {
+		t.Fatalf("not expected %s", actual)
+	}
+	if actual := SpeedLimitString(2500); actual != "2.5 MB/s" {
+		t.Fatalf("not expected %s", actual)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("18:00-23:30 2MB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := SpeedSchedule{From: 18 * 60, To: 23*60 + 30, Limits: SpeedLimits{Down: 2000, DownEnabled: true}}
+	if schedule != expected {
+		t.Fatalf("not expected %+v", schedule)
+	}
+	if schedule.Hours() != "18:00-23:30" {
+		t.Fatalf("not expected hours %s", schedule.Hours())
+	}
+
+	schedule, err = ParseSchedule("23:00 - 7:00 off 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.Limits.DownEnabled || !schedule.Limits.UpEnabled || schedule.Limits.Up != 100 {
+		t.Fatalf("not expected %+v", schedule.Limits)
+	}
+
+	for _, input := range []string{"18:00 2MB", "25:00-23:00 2MB", "18:00-18:00 2MB", "18:00-23:00 fast"} {
+		if _, err := ParseSchedule(input); err == nil {
+			t.Fatalf("%s must be invalid", input)
+		}
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+	evening := SpeedSchedule{From: 18 * 60, To: 23 * 60}
+	if !evening.Active(at(18, 0)) || !evening.Active(at(22, 59)) || evening.Active(at(23, 0)) || evening.Active(at(9, 0)) {
+		t.Fatal("wrong evening schedule")
+	}
+	night := SpeedSchedule{From: 23 * 60, To: 7 * 60}
+	if !night.Active(at(23, 30)) || !night.Active(at(3, 0)) || night.Active(at(7, 0)) || night.Active(at(12, 0)) {
+		t.Fatal("wrong night schedule")
+	}
+}
+
+func TestSchedulesAreSaved(t *testing.T) {
+	defer func() { schedules = &scheduleRegistry{} }()
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	schedules = &scheduleRegistry{}
+	if err := LoadSchedules(path); err != nil {
+		t.Fatal(err)
+	}
+	first := SpeedSchedule{From: 60, To: 120}
+	second := SpeedSchedule{From: 180, To: 240}
+	if err := AddSchedule(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddSchedule(second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RemoveSchedule(2); err != ErrNoSchedule {
+		t.Fatalf("expected ErrNoSchedule, actual %v", err)
+	}
+	if removed, err := RemoveSchedule(0); err != nil || removed != first {
+		t.Fatalf("not expected %+v %v", removed, err)
+	}
+
+	schedules = &scheduleRegistry{}
+	if err := LoadSchedules(path); err != nil {
+		t.Fatal(err)
+	}
+	if list := Schedules(); len(list) != 1 || list[0] != second {
+		t.Fatalf("not expected %+v", list)
+	}
+}
@@ -0,0 +1,136 @@
+package transmission
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hekmon/transmissionrpc/v3"
+)
+
+// transmission speed limits are in kB/s, 1 kB is 1000 bytes
+const speedUnit = 1000
+
+// SpeedLimits are global limits in kB/s, a limit is used only if it is enabled
+type SpeedLimits struct {
+	Down        int64 `json:"down"`
+	DownEnabled bool  `json:"down_enabled"`
+	Up          int64 `json:"up"`
+	UpEnabled   bool  `json:"up_enabled"`
+}
+
+// SpeedSettings are limits of the session, alternative limits are used instead when turtle mode is on
+type SpeedSettings struct {
+	Limits     SpeedLimits
+	AltDown    int64
+	AltUp      int64
+	AltEnabled bool
+}
+
+var speedFields = []string{"speed-limit-down", "speed-limit-down-enabled", "speed-limit-up", "speed-limit-up-enabled", "alt-speed-down", "alt-speed-up", "alt-speed-enabled"}
+
+// returns global speed limits and turtle mode of transmission
+func GetSpeedSettings(ctx context.Context) (SpeedSettings, error) {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return SpeedSettings{}, err
+	}
+
+	session, err := tbt.SessionArgumentsGet(ctx, speedFields)
+	if err != nil {
+		return SpeedSettings{}, err
+	}
+
+	var settings SpeedSettings
+	if session.SpeedLimitDown != nil {
+		settings.Limits.Down = *session.SpeedLimitDown
+	}
+	if session.SpeedLimitDownEnabled != nil {
+		settings.Limits.DownEnabled = *session.SpeedLimitDownEnabled
+	}
+	if session.SpeedLimitUp != nil {
+		settings.Limits.Up = *session.SpeedLimitUp
+	}
+	if session.SpeedLimitUpEnabled != nil {
+		settings.Limits.UpEnabled = *session.SpeedLimitUpEnabled
+	}
+	if session.AltSpeedDown != nil {
+		settings.AltDown = *session.AltSpeedDown
+	}
+	if session.AltSpeedUp != nil {
+		settings.AltUp = *session.AltSpeedUp
+	}
+	if session.AltSpeedEnabled != nil {
+		settings.AltEnabled = *session.AltSpeedEnabled
+	}
+	return settings, nil
+}
+
+// set global speed limits, value of disabled limit is kept
+func SetSpeedLimits(ctx context.Context, limits SpeedLimits) error {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload := transmissionrpc.SessionArguments{
+		SpeedLimitDownEnabled: &limits.DownEnabled,
+		SpeedLimitUpEnabled:   &limits.UpEnabled,
+	}
+	if limits.DownEnabled {
+		payload.SpeedLimitDown = &limits.Down
+	}
+	if limits.UpEnabled {
+		payload.SpeedLimitUp = &limits.Up
+	}
+	return tbt.SessionArgumentsSet(ctx, payload)
+}
+
+// turn turtle mode on or off
+func SetAltSpeed(ctx context.Context, enabled bool) error {
+	tbt, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	return tbt.SessionArgumentsSet(ctx, transmissionrpc.SessionArguments{AltSpeedEnabled: &enabled})
+}
+
+// speed like 500, 500k, 2MB, 1.5 mb/s, number without unit is in kB/s
+var speedRegexp = regexp.MustCompile(`^(?i)(\d+(?:[.,]\d+)?)\s*(k|kb|m|mb|кб|мб)?(?:/s|/с)?$`)
+
+// parse limit in kB/s, 0, off or - means unlimited and returns false
+func ParseSpeedLimit(text string) (int64, bool, error) {
+	text = strings.TrimSpace(text)
+	switch strings.ToLower(text) {
+	case "0", "off", "-":
+		return 0, false, nil
+	}
+
+	match := speedRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false, fmt.Errorf("invalid speed %q", text)
+	}
+	value, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, false, err
+	}
+	switch strings.ToLower(match[2]) {
+	case "m", "mb", "мб":
+		value *= speedUnit
+	}
+	if value < 1 {
+		return 0, false, fmt.Errorf("speed %q is less than 1 kB/s", text)
+	}
+	return int64(value), true, nil
+}
+
+// limit in kB/s like 500 kB/s or 2.5 MB/s
+func SpeedLimitString(limit int64) string {
+	if limit < speedUnit {
+		return fmt.Sprintf("%d kB/s", limit)
+	}
+	return strconv.FormatFloat(float64(limit)/speedUnit, 'f', -1, 64) + " MB/s"
+}
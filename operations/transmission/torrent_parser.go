@@ -1,7 +1,10 @@
 package transmission
 
 import (
+	"io"
+	"net/url"
 	"os"
+	"sort"
 
 	"github.com/anacrolix/torrent/metainfo"
 )
@@ -30,3 +33,92 @@ func GetTorrentTitle(path string) (string, error) {
 	// Return title
 	return info.Name, nil
 }
+
+// MagnetInfo is what a magnet link tells about the torrent
+type MagnetInfo struct {
+	Name     string
+	InfoHash string
+	Trackers []string
+}
+
+// ParseMagnet reads display name, infohash and trackers of a magnet link
+func ParseMagnet(uri string) (MagnetInfo, error) {
+	magnet, err := metainfo.ParseMagnetUri(uri)
+	if err != nil {
+		return MagnetInfo{}, err
+	}
+	return MagnetInfo{Name: magnet.DisplayName, InfoHash: magnet.InfoHash.HexString(), Trackers: magnet.Trackers}, nil
+}
+
+// TrackerHosts returns host names of trackers, without duplicates
+func (magnet MagnetInfo) TrackerHosts() []string {
+	return trackerHosts(magnet.Trackers)
+}
+
+// TorrentFile is a file inside of the torrent
+type TorrentFile struct {
+	Path string
+	Size int64
+}
+
+// TorrentInfo is what a .torrent file tells about the torrent
+type TorrentInfo struct {
+	Name        string
+	InfoHash    string
+	TotalSize   int64
+	Files       []TorrentFile // biggest first
+	PieceLength int64
+	Private     bool
+	Trackers    []string
+}
+
+// ParseTorrent reads metainfo of .torrent file
+func ParseTorrent(reader io.Reader) (TorrentInfo, error) {
+	mi, err := metainfo.Load(reader)
+	if err != nil {
+		return TorrentInfo{}, err
+	}
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return TorrentInfo{}, err
+	}
+
+	torrent := TorrentInfo{
+		Name:        info.BestName(),
+		InfoHash:    mi.HashInfoBytes().HexString(),
+		TotalSize:   info.TotalLength(),
+		PieceLength: info.PieceLength,
+		Private:     info.Private != nil && *info.Private,
+	}
+	for _, file := range info.UpvertedFiles() {
+		torrent.Files = append(torrent.Files, TorrentFile{Path: file.DisplayPath(&info), Size: file.Length})
+	}
+	sort.SliceStable(torrent.Files, func(i, j int) bool {
+		return torrent.Files[i].Size > torrent.Files[j].Size
+	})
+	for _, tier := range mi.UpvertedAnnounceList() {
+		torrent.Trackers = append(torrent.Trackers, tier...)
+	}
+	return torrent, nil
+}
+
+// TrackerHosts returns host names of trackers, without duplicates
+func (torrent TorrentInfo) TrackerHosts() []string {
+	return trackerHosts(torrent.Trackers)
+}
+
+func trackerHosts(trackers []string) []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, tracker := range trackers {
+		host := tracker
+		if parsed, err := url.Parse(tracker); err == nil && parsed.Hostname() != "" {
+			host = parsed.Hostname()
+		}
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
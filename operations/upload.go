@@ -0,0 +1,236 @@
+package operations
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// folder with more files is sent as one zip archive
+const maxSeparateFiles = 10
+
+// not more parts are sent for one download, so a movie is not uploaded by mistake
+const maxUploadParts = 20
+
+var ErrTooBigToUpload = errors.New("too big to upload")
+var ErrNotInFolder = errors.New("not an entry of the folder")
+
+// FolderEntry returns path of file or folder name directly inside folder,
+// name comes from a button, so the folder itself or anything outside of it is refused
+func FolderEntry(folder string, name string) (string, error) {
+	if name == "" {
+		return "", ErrNotInFolder
+	}
+	path := filepath.Join(folder, name)
+	relative, err := filepath.Rel(folder, path)
+	if err != nil {
+		return "", err
+	}
+	if relative != name || relative == "." || relative == ".." || relative != filepath.Base(relative) {
+		return "", ErrNotInFolder
+	}
+	return path, nil
+}
+
+// UploadPart is a file or a piece of a file sent as a document, Stream must be closed
+type UploadPart struct {
+	Name   string
+	Size   int64
+	Stream io.ReadCloser
+}
+
+// PrepareUpload opens file or folder of finished download as parts not bigger than limit bytes,
+// folder with many files is zipped to temporary file, big files are split to name.001, name.002...
+func PrepareUpload(path string, limit int64) ([]UploadPart, error) {
+	files, total, err := listFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if total > limit*maxUploadParts {
+		return nil, ErrTooBigToUpload
+	}
+
+	var parts []UploadPart
+	if len(files) > maxSeparateFiles {
+		archive, err := zipFolder(path)
+		if err != nil {
+			return nil, err
+		}
+		parts, err = splitFile(archive, filepath.Base(path)+".zip", limit, true)
+		if err != nil {
+			os.Remove(archive)
+			return nil, err
+		}
+	} else {
+		for _, file := range files {
+			fileParts, err := splitFile(file, filepath.Base(file), limit, false)
+			if err != nil {
+				closeParts(parts)
+				return nil, err
+			}
+			parts = append(parts, fileParts...)
+		}
+	}
+
+	if len(parts) > maxUploadParts {
+		closeParts(parts)
+		return nil, ErrTooBigToUpload
+	}
+	return parts, nil
+}
+
+// regular files of the folder in order of names, or the file itself, and their total size
+func listFiles(path string) ([]string, int64, error) {
+	var files []string
+	var total int64
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+		total += info.Size()
+		return nil
+	})
+	sort.Strings(files)
+	return files, total, err
+}
+
+// zip folder to temporary file, paths in the archive start with name of the folder
+func zipFolder(path string) (string, error) {
+	archive, err := os.CreateTemp("", "upload-*.zip")
+	if err != nil {
+		return "", err
+	}
+
+	writer := zip.NewWriter(archive)
+	files, _, err := listFiles(path)
+	for _, file := range files {
+		if err != nil {
+			break
+		}
+		var name string
+		if name, err = filepath.Rel(path, file); err == nil {
+			err = addToZip(writer, file, filepath.Join(filepath.Base(path), name))
+		}
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(archive.Name())
+		return "", err
+	}
+	return archive.Name(), nil
+}
+
+func addToZip(writer *zip.Writer, file string, name string) error {
+	source, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	// most of downloads are already compressed, so files are only stored
+	target, err := writer.CreateHeader(&zip.FileHeader{Name: filepath.ToSlash(name), Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(target, source)
+	return err
+}
+
+// file as one part, or parts name.001, name.002... of limit bytes if it is bigger
+func splitFile(path string, name string, limit int64, temporary bool) ([]UploadPart, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	size := info.Size()
+	count := int((size + limit - 1) / limit)
+	if count == 0 {
+		count = 1
+	}
+	shared := &sharedFile{file: file, temporary: temporary, open: count}
+	if count == 1 {
+		return []UploadPart{{Name: name, Size: size, Stream: &filePart{SectionReader: io.NewSectionReader(file, 0, size), shared: shared}}}, nil
+	}
+
+	var parts []UploadPart
+	for i := 0; i < count; i++ {
+		offset := int64(i) * limit
+		length := limit
+		if offset+length > size {
+			length = size - offset
+		}
+		parts = append(parts, UploadPart{
+			Name:   fmt.Sprintf("%s.%03d", name, i+1),
+			Size:   length,
+			Stream: &filePart{SectionReader: io.NewSectionReader(file, offset, length), shared: shared},
+		})
+	}
+	return parts, nil
+}
+
+func closeParts(parts []UploadPart) {
+	for _, part := range parts {
+		part.Stream.Close()
+	}
+}
+
+// file is closed when all of its parts are closed, temporary file is removed then
+type sharedFile struct {
+	mutex     sync.Mutex
+	file      *os.File
+	temporary bool
+	open      int
+}
+
+func (shared *sharedFile) release() error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	shared.open--
+	if shared.open > 0 {
+		return nil
+	}
+	err := shared.file.Close()
+	if shared.temporary {
+		os.Remove(shared.file.Name())
+	}
+	return err
+}
+
+type filePart struct {
+	*io.SectionReader
+	shared *sharedFile
+	once   sync.Once
+}
+
+func (part *filePart) Close() error {
+	var err error
+	part.once.Do(func() {
+		err = part.shared.release()
+	})
+	return err
+}
@@ -0,0 +1,107 @@
+package operations
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, size int) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, bytes.Repeat([]byte("a"), size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readParts(t *testing.T, parts []UploadPart) [][]byte {
+	var result [][]byte
+	for _, part := range parts {
+		data, err := io.ReadAll(part.Stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(data)) != part.Size {
+			t.Fatalf("part %s has %d bytes, expected %d", part.Name, len(data), part.Size)
+		}
+		part.Stream.Close()
+		result = append(result, data)
+	}
+	return result
+}
+
+func TestPrepareUploadSplitsBigFile(t *testing.T) {
+	folder := t.TempDir()
+	writeFile(t, filepath.Join(folder, "Book", "book.pdf"), 25)
+	writeFile(t, filepath.Join(folder, "Book", "cover.jpg"), 5)
+
+	parts, err := PrepareUpload(filepath.Join(folder, "Book"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, part := range parts {
+		names = append(names, part.Name)
+	}
+	if fmt.Sprint(names) != "[book.pdf.001 book.pdf.002 book.pdf.003 cover.jpg]" {
+		t.Fatalf("not expected parts %v", names)
+	}
+	data := readParts(t, parts)
+	if len(data[2]) != 5 {
+		t.Fatalf("last part should have the rest of the file")
+	}
+}
+
+func TestPrepareUploadZipsManyFiles(t *testing.T) {
+	folder := t.TempDir()
+	for i := 0; i <= maxSeparateFiles; i++ {
+		writeFile(t, filepath.Join(folder, "Audiobook", "cd", fmt.Sprintf("%02d.mp3", i)), 3)
+	}
+
+	parts, err := PrepareUpload(filepath.Join(folder, "Audiobook"), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 1 || parts[0].Name != "Audiobook.zip" {
+		t.Fatalf("not expected parts %v", parts)
+	}
+	data := readParts(t, parts)[0]
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.File) != maxSeparateFiles+1 || archive.File[0].Name != "Audiobook/cd/00.mp3" {
+		t.Fatalf("not expected archive %v", archive.File[0].Name)
+	}
+}
+
+func TestPrepareUploadRejectsTooBig(t *testing.T) {
+	folder := t.TempDir()
+	writeFile(t, filepath.Join(folder, "movie.mkv"), maxUploadParts*10+1)
+
+	if _, err := PrepareUpload(filepath.Join(folder, "movie.mkv"), 10); !errors.Is(err, ErrTooBigToUpload) {
+		t.Fatalf("expected too big error, got %v", err)
+	}
+	if _, err := PrepareUpload(filepath.Join(folder, "missing"), 10); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+}
+
+func TestFolderEntryRefusesFolderAndOutside(t *testing.T) {
+	folder := t.TempDir()
+	path, err := FolderEntry(folder, "movie.mkv")
+	if err != nil || path != filepath.Join(folder, "movie.mkv") {
+		t.Fatalf("not expected %s %v", path, err)
+	}
+	for _, name := range []string{".", "..", "", "a/b", "../other", "/etc", "a/.."} {
+		if path, err := FolderEntry(folder, name); err == nil {
+			t.Fatalf("%q must be refused, got %s", name, path)
+		}
+	}
+}
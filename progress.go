@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/telegram-command-reader/bot"
+	"github.com/telegram-command-reader/i18n"
+	transmission "github.com/telegram-command-reader/operations/transmission"
+)
+
+// progress message is edited not more often than this to stay within telegram limits
+const progressInterval = 5 * time.Second
+
+// send message and wait until telegram returns its id, 0 if sending failed
+func sendAndWaitForID(message bot.OutMessage, outputChannel chan bot.OutMessage) int {
+	sent := make(chan int, 1)
+	message.OnSent = func(id int) {
+		sent <- id
+	}
+	outputChannel <- message
+
+	select {
+	case id := <-sent:
+		return id
+	case <-time.After(time.Minute):
+		return 0
+	}
+}
+
+// refresh one message with progress of the torrent until it completes,
+// new message is sent if messageID is 0, the watch is kept for restart if the bot stops earlier
+func trackProgress(originalMessage *bot.Info, torrentID int64, messageID int, outputChannel chan bot.OutMessage) {
+	ctx := originalMessage.Context()
+	watch := progressWatch{Ref: originalMessage.Ref(), TorrentID: torrentID, MessageID: messageID}
+	watchers.put(watch)
+
+	lastText := ""
+	failures := 0
+	for {
+		torrent, err := transmission.GetTorrent(ctx, torrentID)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			failures++
+			if failures > 5 {
+				watchers.remove(watch)
+				outputChannel <- bot.OutMessage{OriginalMessage: originalMessage, Text: originalMessage.T(i18n.StoppedWatching, err)}
+				return
+			}
+			if !sleepContext(ctx, progressInterval) {
+				return
+			}
+			continue
+		}
+		failures = 0
+
+		text := transmission.ProgressString(torrent, originalMessage.Language())
+		if messageID == 0 {
+			messageID = sendAndWaitForID(bot.OutMessage{OriginalMessage: originalMessage, Text: text}, outputChannel)
+			if messageID == 0 {
+				fmt.Println("could not send progress message")
+				watchers.remove(watch)
+				return
+			}
+			watch.MessageID = messageID
+			watchers.put(watch)
+		} else if text != lastText {
+			outputChannel <- bot.OutMessage{OriginalMessage: originalMessage, Text: text, EditMessageID: messageID}
+		}
+		lastText = text
+
+		if !watch.FilesOffered && offerFileSelection(originalMessage, torrentID, outputChannel) {
+			watch.FilesOffered = true
+			watchers.put(watch)
+		}
+
+		if torrent.PercentDone != nil && *torrent.PercentDone >= 1 {
+			watchers.remove(watch)
+			name := ""
+			if torrent.Name != nil {
+				name = *torrent.Name
+			}
+			outputChannel <- finishedMessage(originalMessage, name)
+			return
+		}
+
+		if !sleepContext(ctx, progressInterval) {
+			return
+		}
+	}
+}
+
+// send file selection if torrent has several files, returns false if files are not known yet
+func offerFileSelection(originalMessage *bot.Info, torrentID int64, outputChannel chan bot.OutMessage) bool {
+	torrent, err := transmission.GetTorrentFiles(originalMessage.Context(), torrentID)
+	if err != nil || len(torrent.Files) == 0 {
+		return false
+	}
+	if len(torrent.Files) > 1 {
+		showTorrentFiles(originalMessage, torrentID, 0, bot.FilesModeWanted, 0, outputChannel)
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/telegram-command-reader/bot"
+	"github.com/telegram-command-reader/i18n"
+	transmission "github.com/telegram-command-reader/operations/transmission"
+)
+
+// how often speed schedules are checked
+const scheduleInterval = time.Minute
+
+// send global speed limits, admin also gets turtle mode button, the message is edited if editMessageID is set
+func showSpeed(message *bot.Info, editMessageID int, outputChannel chan bot.OutMessage) {
+	settings, err := transmission.GetSpeedSettings(message.Context())
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.TransmissionError, err)}
+		return
+	}
+
+	reply := bot.OutMessage{OriginalMessage: message, Text: formatSpeedSettings(settings, message.Language()), EditMessageID: editMessageID}
+	// buttons change limits, members would only get refusals
+	if bot.RoleOf(message) >= bot.RoleAdmin {
+		reply.UseInlineKeyboard = true
+		reply.InlineKeyboard = bot.SpeedKeyboard(message.Language(), bot.NewAction(actionSpeed, nil), settings.AltEnabled)
+	}
+	outputChannel <- reply
+}
+
+// set download and optional upload limits like 2MB 500KB, upload limit is kept if it is missing
+func setSpeed(message *bot.Info, args []string, outputChannel chan bot.OutMessage) {
+	ctx := message.Context()
+	settings, err := transmission.GetSpeedSettings(ctx)
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.TransmissionError, err)}
+		return
+	}
+
+	limits := settings.Limits
+	if limits.Down, limits.DownEnabled, err = transmission.ParseSpeedLimit(args[0]); err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.BadSpeed, err)}
+		return
+	}
+	if len(args) > 1 {
+		if limits.Up, limits.UpEnabled, err = transmission.ParseSpeedLimit(args[1]); err != nil {
+			outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.BadSpeed, err)}
+			return
+		}
+	}
+
+	if err := transmission.SetSpeedLimits(ctx, limits); err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ControlError, err)}
+		return
+	}
+	showSpeed(message, 0, outputChannel)
+}
+
+// apply pressed button of speed message: turtle mode on or off, or no limits
+func changeSpeed(ctx context.Context, button string) error {
+	switch button {
+	case bot.TurtleOn, bot.TurtleOff:
+		return transmission.SetAltSpeed(ctx, button == bot.TurtleOn)
+	case bot.SpeedUnlimited:
+		return transmission.SetSpeedLimits(ctx, transmission.SpeedLimits{})
+	}
+	return fmt.Errorf("unknown button %s", button)
+}
+
+// add schedule like 18:00-23:00 2MB 500KB, it is applied at once if it is active now
+func addSchedule(message *bot.Info, text string, outputChannel chan bot.OutMessage) {
+	schedule, err := transmission.ParseSchedule(strings.TrimSpace(text))
+	if err != nil {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.BadSchedule, err)}
+		return
+	}
+	if err := transmission.AddSchedule(schedule); err != nil {
+		fmt.Println("save schedules error ", err)
+	}
+	if err := transmission.ApplySchedules(message.Context(), time.Now()); err != nil {
+		fmt.Println("apply speed schedules error ", err)
+	}
+	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ScheduleAdded, schedule.Hours())}
+}
+
+// remove schedule with number from 1, limits before it are set back if it is active now
+func removeSchedule(message *bot.Info, number string, outputChannel chan bot.OutMessage) {
+	index, _ := strconv.Atoi(number)
+	schedule, err := transmission.RemoveSchedule(index - 1)
+	if err == transmission.ErrNoSchedule {
+		outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.NoSuchSchedule, number)}
+		return
+	}
+	if err != nil {
+		fmt.Println("save schedules error ", err)
+	}
+	if err := transmission.ApplySchedules(message.Context(), time.Now()); err != nil {
+		fmt.Println("apply speed schedules error ", err)
+	}
+	outputChannel <- bot.OutMessage{OriginalMessage: message, Text: message.T(i18n.ScheduleRemoved, schedule.Hours())}
+}
+
+// apply speed schedules every minute until ctx is done
+func applySpeedSchedules(ctx context.Context) {
+	for {
+		if err := transmission.ApplySchedules(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			fmt.Println("apply speed schedules error ", err)
+		}
+		if !sleepContext(ctx, scheduleInterval) {
+			return
+		}
+	}
+}
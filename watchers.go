@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/telegram-command-reader/bot"
+)
+
+// progress message of torrent, it is saved on shutdown to continue after restart
+type progressWatch struct {
+	Ref          bot.MessageRef `json:"ref"`
+	TorrentID    int64          `json:"torrent_id"`
+	MessageID    int            `json:"message_id"` // 0 if progress message is not sent yet
+	FilesOffered bool           `json:"files_offered"`
+}
+
+// background goroutines watching downloads, they stop when the work context is done
+type watchRegistry struct {
+	mutex   sync.Mutex
+	watches map[string]progressWatch
+	running sync.WaitGroup
+}
+
+var watchers = &watchRegistry{watches: make(map[string]progressWatch)}
+
+func watchKey(ref bot.MessageRef, torrentID int64) string {
+	return fmt.Sprintf("%d:%d", ref.ChatID, torrentID)
+}
+
+// run watcher in own goroutine, shutdown waits for it
+func (registry *watchRegistry) start(watcher func()) {
+	registry.running.Add(1)
+	go func() {
+		defer registry.running.Done()
+		watcher()
+	}()
+}
+
+func (registry *watchRegistry) put(watch progressWatch) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.watches[watchKey(watch.Ref, watch.TorrentID)] = watch
+}
+
+func (registry *watchRegistry) remove(watch progressWatch) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	delete(registry.watches, watchKey(watch.Ref, watch.TorrentID))
+}
+
+// wait until watchers return, error if ctx is done earlier
+func (registry *watchRegistry) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		registry.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// save unfinished watches, file is removed if there are none
+func (registry *watchRegistry) save(path string) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if len(registry.watches) == 0 {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var list []progressWatch
+	for _, watch := range registry.watches {
+		list = append(list, watch)
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// watches saved on last shutdown, the file is removed so they are not resumed twice
+func loadWatches(path string) ([]progressWatch, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []progressWatch
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	return list, os.Remove(path)
+}
+
+// continue progress messages of the last run
+func resumeWatches(path string, outputChannel chan bot.OutMessage) {
+	list, err := loadWatches(path)
+	if err != nil {
+		fmt.Println("Load watchers error ", err)
+	}
+	for _, watch := range list {
+		watch := watch
+		fmt.Println("resume progress of torrent ", watch.TorrentID)
+		watchers.start(func() {
+			trackProgress(bot.RestoreInfo(watch.Ref), watch.TorrentID, watch.MessageID, outputChannel)
+		})
+	}
+}
+
+// sleep which returns false when ctx is done
+func sleepContext(ctx context.Context, duration time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}